@@ -0,0 +1,34 @@
+// Package cache define a interface de cache usada pelo FinderService e uma
+// implementação LRU em memória, limitada por número de entradas e por bytes,
+// com expiração (TTL) por entrada.
+package cache
+
+import "time"
+
+// Cache é um armazenamento chave/valor com expiração por entrada.
+// Implementações devem ser seguras para uso concorrente.
+type Cache[V any] interface {
+	// Get retorna o valor da chave, se presente e não expirado.
+	Get(key string) (V, bool)
+	// Set armazena o valor. ttl <= 0 significa sem expiração.
+	Set(key string, value V, ttl time.Duration)
+	// Delete remove a chave, se presente.
+	Delete(key string)
+	// DeleteFunc remove as entradas para as quais match retorna true e
+	// retorna quantas foram removidas.
+	DeleteFunc(match func(key string, value V) bool) int
+	// Len retorna o número de entradas armazenadas.
+	Len() int
+	// Stats retorna os contadores do cache.
+	Stats() Stats
+}
+
+// Stats são os contadores acumulados de um cache.
+type Stats struct {
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+	Entries     int    `json:"entries"`
+	Bytes       int64  `json:"bytes"`
+}
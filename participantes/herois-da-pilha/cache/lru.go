@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU é um Cache em memória que descarta as entradas usadas há mais tempo
+// quando MaxEntries ou MaxBytes é excedido. Entradas expiradas são removidas
+// de forma preguiçosa, na leitura ou quando chegam ao fim da lista.
+type LRU[V any] struct {
+	mu         sync.Mutex
+	ll         *list.List
+	items      map[string]*list.Element
+	maxEntries int
+	maxBytes   int64
+	sizeOf     func(key string, value V) int64
+	bytes      int64
+	stats      Stats
+}
+
+type entry[V any] struct {
+	key       string
+	value     V
+	size      int64
+	expiresAt time.Time
+}
+
+// Options configura um LRU.
+type Options[V any] struct {
+	// MaxEntries limita o número de entradas (0 = sem limite).
+	MaxEntries int
+	// MaxBytes limita o tamanho estimado total (0 = sem limite). Requer SizeOf.
+	MaxBytes int64
+	// SizeOf estima o tamanho em bytes de uma entrada.
+	SizeOf func(key string, value V) int64
+}
+
+// NewLRU cria um LRU com as opções informadas.
+func NewLRU[V any](opts Options[V]) *LRU[V] {
+	sizeOf := opts.SizeOf
+	if sizeOf == nil {
+		sizeOf = func(key string, _ V) int64 { return int64(len(key)) }
+	}
+	return &LRU[V]{
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		maxEntries: opts.MaxEntries,
+		maxBytes:   opts.MaxBytes,
+		sizeOf:     sizeOf,
+	}
+}
+
+// Get implementa Cache.
+func (c *LRU[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		c.stats.Misses++
+		return zero, false
+	}
+
+	e := el.Value.(*entry[V])
+	if c.expired(e) {
+		c.removeElement(el)
+		c.stats.Expirations++
+		c.stats.Misses++
+		return zero, false
+	}
+
+	c.ll.MoveToFront(el)
+	c.stats.Hits++
+	return e.value, true
+}
+
+// Set implementa Cache.
+func (c *LRU[V]) Set(key string, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	size := c.sizeOf(key, value)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[V])
+		c.bytes += size - e.size
+		e.value, e.size, e.expiresAt = value, size, expiresAt
+		c.ll.MoveToFront(el)
+	} else {
+		c.items[key] = c.ll.PushFront(&entry[V]{key: key, value: value, size: size, expiresAt: expiresAt})
+		c.bytes += size
+	}
+
+	c.evict()
+}
+
+// Delete implementa Cache.
+func (c *LRU[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// DeleteFunc implementa Cache.
+func (c *LRU[V]) DeleteFunc(match func(key string, value V) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deleted := 0
+	for el := c.ll.Front(); el != nil; {
+		next := el.Next()
+		if e := el.Value.(*entry[V]); match(e.key, e.value) {
+			c.removeElement(el)
+			deleted++
+		}
+		el = next
+	}
+	return deleted
+}
+
+// Len implementa Cache.
+func (c *LRU[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Stats implementa Cache.
+func (c *LRU[V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.ll.Len()
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// Entries implementa Snapshotter.
+func (c *LRU[V]) Entries() []Entry[V] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := make([]Entry[V], 0, c.ll.Len())
+	for el := c.ll.Back(); el != nil; el = el.Prev() {
+		e := el.Value.(*entry[V])
+		if c.expired(e) {
+			continue
+		}
+		entries = append(entries, Entry[V]{Key: e.key, Value: e.value, ExpiresAt: e.expiresAt})
+	}
+	return entries
+}
+
+// evict remove entradas do fim da lista até respeitar os limites.
+// Deve ser chamado com o mutex travado.
+func (c *LRU[V]) evict() {
+	for c.ll.Len() > 0 && c.overLimit() {
+		el := c.ll.Back()
+		if c.expired(el.Value.(*entry[V])) {
+			c.stats.Expirations++
+		} else {
+			c.stats.Evictions++
+		}
+		c.removeElement(el)
+	}
+}
+
+func (c *LRU[V]) overLimit() bool {
+	return (c.maxEntries > 0 && c.ll.Len() > c.maxEntries) ||
+		(c.maxBytes > 0 && c.bytes > c.maxBytes)
+}
+
+func (c *LRU[V]) expired(e *entry[V]) bool {
+	return !e.expiresAt.IsZero() && time.Now().After(e.expiresAt)
+}
+
+func (c *LRU[V]) removeElement(el *list.Element) {
+	e := c.ll.Remove(el).(*entry[V])
+	delete(c.items, e.key)
+	c.bytes -= e.size
+}
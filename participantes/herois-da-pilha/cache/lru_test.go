@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// present retorna as chaves de want que estão no cache.
+func present(c *LRU[int], want ...string) []string {
+	var out []string
+	for _, k := range want {
+		if _, ok := c.Get(k); ok {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// keys retorna as chaves do cache, da usada há mais tempo à mais recente.
+func keys(c *LRU[int]) []string {
+	var out []string
+	for _, e := range c.Entries() {
+		out = append(out, e.Key)
+	}
+	return out
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU(Options[int]{MaxEntries: 3})
+	for i, k := range []string{"a", "b", "c"} {
+		c.Set(k, i, 0)
+	}
+	c.Get("a") // "b" passa a ser a usada há mais tempo
+	c.Set("d", 3, 0)
+
+	if s := c.Stats(); s.Evictions != 1 || s.Entries != 3 {
+		t.Errorf("stats = %+v, want 1 descarte e 3 entradas", s)
+	}
+	if got := present(c, "a", "b", "c", "d"); len(got) != 3 || got[0] != "a" || got[1] != "c" || got[2] != "d" {
+		t.Fatalf("entradas = %v, want [a c d] (b descartada)", got)
+	}
+}
+
+func TestLRUByteLimit(t *testing.T) {
+	c := NewLRU(Options[int]{
+		MaxBytes: 10,
+		SizeOf:   func(_ string, v int) int64 { return int64(v) },
+	})
+	c.Set("a", 4, 0)
+	c.Set("b", 4, 0)
+	if s := c.Stats(); s.Bytes != 8 {
+		t.Fatalf("bytes = %d, want 8", s.Bytes)
+	}
+
+	// Sobrescrever ajusta a contagem pela diferença de tamanho
+	c.Set("a", 2, 0)
+	if s := c.Stats(); s.Bytes != 6 || s.Evictions != 0 {
+		t.Fatalf("após sobrescrever: %+v, want 6 bytes sem descartes", s)
+	}
+
+	// "b" é a usada há mais tempo e sai para caber a nova entrada
+	c.Set("c", 5, 0)
+	if s := c.Stats(); s.Bytes != 7 || s.Evictions != 1 {
+		t.Errorf("stats = %+v, want 7 bytes e 1 descarte", s)
+	}
+	if got := present(c, "a", "b", "c"); len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Fatalf("entradas = %v, want [a c]", got)
+	}
+
+	c.Delete("a")
+	if s := c.Stats(); s.Bytes != 5 {
+		t.Errorf("após Delete: bytes = %d, want 5", s.Bytes)
+	}
+}
+
+func TestLRUExpiration(t *testing.T) {
+	c := NewLRU(Options[int]{MaxEntries: 2})
+	c.Set("curta", 1, 10*time.Millisecond)
+	c.Set("longa", 2, time.Hour)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := c.Get("curta"); ok {
+		t.Fatal("entrada expirada retornada")
+	}
+	if v, ok := c.Get("longa"); !ok || v != 2 {
+		t.Fatal("entrada válida não retornada")
+	}
+	if s := c.Stats(); s.Expirations != 1 || s.Misses != 1 || s.Hits != 1 || s.Entries != 1 {
+		t.Errorf("stats = %+v", s)
+	}
+
+	// Uma entrada expirada no fim da lista conta como expiração, não descarte
+	c.Set("curta", 1, 10*time.Millisecond)
+	c.Get("curta")
+	c.Get("longa")
+	time.Sleep(20 * time.Millisecond)
+	c.Set("nova", 3, 0)
+	if s := c.Stats(); s.Expirations != 2 || s.Evictions != 0 {
+		t.Errorf("stats = %+v, want 2 expirações e nenhum descarte", s)
+	}
+}
+
+func TestLRUDeleteFunc(t *testing.T) {
+	c := NewLRU(Options[int]{})
+	for i, k := range []string{"a", "b", "c", "d"} {
+		c.Set(k, i, 0)
+	}
+
+	n := c.DeleteFunc(func(_ string, v int) bool { return v%2 == 0 })
+	if got, want := keys(c), []string{"b", "d"}; n != 2 || !reflect.DeepEqual(got, want) {
+		t.Fatalf("DeleteFunc removeu %d, restaram %v; want 2, %v", n, got, want)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	src := NewLRU(Options[int]{})
+	src.Set("sem-ttl", 1, 0)
+	src.Set("com-ttl", 2, time.Hour)
+	src.Set("expirada", 3, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	saved, err := SaveFile(path, src, "v1")
+	if err != nil || saved != 2 {
+		t.Fatalf("SaveFile = %d, %v; want 2 entradas", saved, err)
+	}
+
+	dst := NewLRU(Options[int]{})
+	loaded, err := LoadFile(path, dst, "v1")
+	if err != nil || loaded != 2 {
+		t.Fatalf("LoadFile = %d, %v; want 2 entradas", loaded, err)
+	}
+	if got, want := keys(dst), []string{"sem-ttl", "com-ttl"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("entradas = %v, want %v (mesma ordem de uso)", got, want)
+	}
+	for _, e := range dst.Entries() {
+		if e.Key == "com-ttl" && time.Until(e.ExpiresAt) < 59*time.Minute {
+			t.Errorf("expiração não preservada: %v", e.ExpiresAt)
+		}
+	}
+
+	if n, err := LoadFile(filepath.Join(t.TempDir(), "inexistente.json"), dst, "v1"); n != 0 || err != nil {
+		t.Errorf("arquivo inexistente: %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestLoadFileRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "entries": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadFile(path, NewLRU(Options[int]{}), ""); err == nil || !strings.Contains(err.Error(), "versão") {
+		t.Fatalf("err = %v, want erro de versão", err)
+	}
+}
+
+func TestLoadFileRejectsStaleConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	src := NewLRU(Options[int]{})
+	src.Set("a", 1, 0)
+	if _, err := SaveFile(path, src, "v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := NewLRU(Options[int]{})
+	if n, err := LoadFile(path, dst, "v2"); n != 0 || !errors.Is(err, ErrStaleSnapshot) {
+		t.Fatalf("LoadFile = %d, %v; want 0, ErrStaleSnapshot", n, err)
+	}
+	if dst.Len() != 0 {
+		t.Errorf("snapshot de outra configuração carregou %d entradas", dst.Len())
+	}
+}
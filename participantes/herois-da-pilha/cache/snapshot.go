@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry é uma entrada exportada de um cache, usada em snapshots.
+type Entry[V any] struct {
+	Key       string    `json:"key"`
+	Value     V         `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Snapshotter é implementado por caches que conseguem exportar suas entradas.
+type Snapshotter[V any] interface {
+	// Entries retorna as entradas válidas, da usada há mais tempo para a mais recente.
+	Entries() []Entry[V]
+}
+
+// snapshotVersion é incrementada quando o formato do arquivo muda.
+const snapshotVersion = 1
+
+type snapshotFile[V any] struct {
+	Version       int        `json:"version"`
+	ConfigVersion string     `json:"config_version"`
+	SavedAt       time.Time  `json:"saved_at"`
+	Entries       []Entry[V] `json:"entries"`
+}
+
+// ErrStaleSnapshot indica um snapshot gravado com outra versão da
+// configuração, cujas entradas podem não valer mais.
+var ErrStaleSnapshot = errors.New("snapshot do cache de outra versão da configuração")
+
+// SaveFile grava as entradas do cache em um arquivo JSON, registrando a
+// versão da configuração que as produziu. A escrita é feita em um arquivo
+// temporário renomeado ao final, para nunca deixar um snapshot truncado caso
+// o processo morra no meio.
+func SaveFile[V any](path string, c Snapshotter[V], configVersion string) (int, error) {
+	snapshot := snapshotFile[V]{
+		Version:       snapshotVersion,
+		ConfigVersion: configVersion,
+		SavedAt:       time.Now().UTC(),
+		Entries:       c.Entries(),
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return 0, fmt.Errorf("erro ao criar snapshot do cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := json.NewEncoder(tmp).Encode(snapshot); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("erro ao gravar snapshot do cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("erro ao gravar snapshot do cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("erro ao gravar snapshot do cache: %w", err)
+	}
+
+	return len(snapshot.Entries), nil
+}
+
+// LoadFile carrega um snapshot gravado por SaveFile no cache, descartando as
+// entradas já expiradas. Um arquivo inexistente não é erro (retorna 0); um
+// snapshot de outra versão da configuração não é carregado (ErrStaleSnapshot).
+func LoadFile[V any](path string, c Cache[V], configVersion string) (int, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("erro ao abrir snapshot do cache: %w", err)
+	}
+	defer f.Close()
+
+	var snapshot snapshotFile[V]
+	if err := json.NewDecoder(f).Decode(&snapshot); err != nil {
+		return 0, fmt.Errorf("erro ao ler snapshot do cache: %w", err)
+	}
+	if snapshot.Version != snapshotVersion {
+		return 0, fmt.Errorf("versão de snapshot do cache não suportada: %d", snapshot.Version)
+	}
+	if snapshot.ConfigVersion != configVersion {
+		return 0, fmt.Errorf("%w: %q (atual %q)", ErrStaleSnapshot, snapshot.ConfigVersion, configVersion)
+	}
+
+	now := time.Now()
+	loaded := 0
+	for _, e := range snapshot.Entries {
+		var ttl time.Duration
+		if !e.ExpiresAt.IsZero() {
+			ttl = e.ExpiresAt.Sub(now)
+			if ttl <= 0 {
+				continue
+			}
+		}
+		c.Set(e.Key, e.Value, ttl)
+		loaded++
+	}
+
+	return loaded, nil
+}
@@ -0,0 +1,163 @@
+package data
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"herois-da-pilha/normalize"
+)
+
+// catalogJSON é o catálogo de serviços padrão embutido no binário.
+//
+//go:embed catalog.json
+var catalogJSON []byte
+
+// Service é um serviço da URA descrito no catálogo.
+type Service struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Examples são intenções de exemplo, somadas ao dataset de intenções.
+	Examples []string `json:"examples,omitempty"`
+	// Synonyms são expressões usadas pelos clientes para pedir o serviço.
+	Synonyms []string `json:"synonyms,omitempty"`
+	// Aliases são nomes alternativos do serviço.
+	Aliases []string `json:"aliases,omitempty"`
+	// Rules são orientações para o modelo de linguagem decidir entre este
+	// serviço e os parecidos; entram no prompt junto com o serviço.
+	Rules []string `json:"rules,omitempty"`
+	// Fallback marca o serviço usado quando a confiança é insuficiente
+	// (no máximo um por catálogo).
+	Fallback bool `json:"fallback,omitempty"`
+}
+
+// Catalog é o catálogo versionado de serviços válidos. É imutável depois de
+// carregado.
+type Catalog struct {
+	Version  string    `json:"version"`
+	Services []Service `json:"services"`
+
+	byID     map[int]int // ID -> índice em Services
+	fallback int
+}
+
+// LoadCatalog lê e valida o catálogo do arquivo JSON informado.
+// Se path estiver vazio, usa o catálogo embutido no binário.
+func LoadCatalog(path string) (*Catalog, error) {
+	raw := catalogJSON
+	if path != "" {
+		var err error
+		raw, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler catálogo de serviços: %w", err)
+		}
+	}
+	return ParseCatalog(raw)
+}
+
+// ParseCatalog decodifica e valida um catálogo em JSON. Os serviços ficam
+// ordenados por ID.
+func ParseCatalog(raw []byte) (*Catalog, error) {
+	var c Catalog
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar catálogo de serviços: %w", err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("catálogo de serviços inválido: %w", err)
+	}
+	return &c, nil
+}
+
+// validate verifica o catálogo e monta os índices. IDs devem ser positivos e
+// únicos; nomes e aliases não podem se repetir (ignorando caixa e acentos).
+func (c *Catalog) validate() error {
+	if strings.TrimSpace(c.Version) == "" {
+		return fmt.Errorf("version não informada")
+	}
+	if len(c.Services) == 0 {
+		return fmt.Errorf("nenhum serviço definido")
+	}
+
+	sort.SliceStable(c.Services, func(i, j int) bool { return c.Services[i].ID < c.Services[j].ID })
+
+	c.byID = make(map[int]int, len(c.Services))
+	names := make(map[string]int)
+	for i, svc := range c.Services {
+		if svc.ID <= 0 {
+			return fmt.Errorf("ID de serviço inválido: %d", svc.ID)
+		}
+		if _, dup := c.byID[svc.ID]; dup {
+			return fmt.Errorf("ID de serviço duplicado: %d", svc.ID)
+		}
+		c.byID[svc.ID] = i
+
+		if strings.TrimSpace(svc.Name) == "" {
+			return fmt.Errorf("serviço %d sem nome", svc.ID)
+		}
+		for _, name := range append([]string{svc.Name}, svc.Aliases...) {
+			key := normalize.Normalize(name)
+			if other, dup := names[key]; dup {
+				return fmt.Errorf("nome %q duplicado nos serviços %d e %d", name, other, svc.ID)
+			}
+			names[key] = svc.ID
+		}
+
+		if svc.Fallback {
+			if c.fallback != 0 {
+				return fmt.Errorf("mais de um serviço de fallback (%d e %d)", c.fallback, svc.ID)
+			}
+			c.fallback = svc.ID
+		}
+	}
+	return nil
+}
+
+// Lookup retorna o serviço com o ID informado.
+func (c *Catalog) Lookup(id int) (Service, bool) {
+	i, ok := c.byID[id]
+	if !ok {
+		return Service{}, false
+	}
+	return c.Services[i], true
+}
+
+// Name retorna o nome do serviço com o ID informado.
+func (c *Catalog) Name(id int) (string, bool) {
+	svc, ok := c.Lookup(id)
+	return svc.Name, ok
+}
+
+// FallbackID retorna o serviço de fallback ("Atendimento humano"), ou 0 se o
+// catálogo não definir um.
+func (c *Catalog) FallbackID() int {
+	return c.fallback
+}
+
+// IntentExamples retorna os exemplos, sinônimos e aliases do catálogo no
+// formato do dataset de intenções.
+func (c *Catalog) IntentExamples() []IntentExample {
+	var examples []IntentExample
+	for _, svc := range c.Services {
+		for _, list := range [][]string{svc.Examples, svc.Synonyms, svc.Aliases} {
+			for _, intent := range list {
+				examples = append(examples, IntentExample{ServiceID: svc.ID, ServiceName: svc.Name, Intent: intent})
+			}
+		}
+	}
+	return examples
+}
+
+// CheckExamples verifica se todos os exemplos do dataset referenciam serviços
+// do catálogo.
+func (c *Catalog) CheckExamples(examples []IntentExample) error {
+	for _, ex := range examples {
+		if _, ok := c.byID[ex.ServiceID]; !ok {
+			return fmt.Errorf("exemplo %q referencia o serviço %d, ausente do catálogo %s", ex.Intent, ex.ServiceID, c.Version)
+		}
+	}
+	return nil
+}
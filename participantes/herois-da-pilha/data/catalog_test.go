@@ -0,0 +1,79 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadCatalogEmbedded(t *testing.T) {
+	c, err := LoadCatalog("")
+	if err != nil {
+		t.Fatalf("LoadCatalog: %v", err)
+	}
+	if len(c.Services) != 16 {
+		t.Errorf("len(Services) = %d, want 16", len(c.Services))
+	}
+	if name, _ := c.Name(c.FallbackID()); name != "Atendimento humano" {
+		t.Errorf("serviço de fallback = %q, want %q", name, "Atendimento humano")
+	}
+
+	examples, err := LoadIntentExamples("")
+	if err != nil {
+		t.Fatalf("LoadIntentExamples: %v", err)
+	}
+	if err := c.CheckExamples(examples); err != nil {
+		t.Errorf("CheckExamples: %v", err)
+	}
+	for _, ex := range examples {
+		if name, _ := c.Name(ex.ServiceID); name != ex.ServiceName {
+			t.Errorf("serviço %d: nome no dataset %q difere do catálogo %q", ex.ServiceID, ex.ServiceName, name)
+		}
+	}
+}
+
+func TestParseCatalogValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{"válido", `{"version":"1","services":[{"id":2,"name":"B"},{"id":1,"name":"A","fallback":true}]}`, ""},
+		{"sem versão", `{"services":[{"id":1,"name":"A"}]}`, "version"},
+		{"sem serviços", `{"version":"1","services":[]}`, "nenhum serviço"},
+		{"ID inválido", `{"version":"1","services":[{"id":0,"name":"A"}]}`, "ID de serviço inválido"},
+		{"ID duplicado", `{"version":"1","services":[{"id":1,"name":"A"},{"id":1,"name":"B"}]}`, "ID de serviço duplicado"},
+		{"sem nome", `{"version":"1","services":[{"id":1,"name":" "}]}`, "sem nome"},
+		{"nome duplicado ignorando acentos", `{"version":"1","services":[{"id":1,"name":"Cartão"},{"id":2,"name":"cartao"}]}`, "duplicado"},
+		{"alias igual a outro nome", `{"version":"1","services":[{"id":1,"name":"A"},{"id":2,"name":"B","aliases":["a"]}]}`, "duplicado"},
+		{"dois fallbacks", `{"version":"1","services":[{"id":1,"name":"A","fallback":true},{"id":2,"name":"B","fallback":true}]}`, "fallback"},
+		{"JSON inválido", `{`, "decodificar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCatalog([]byte(tt.json))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseCatalog: %v", err)
+				}
+				if c.Services[0].ID != 1 || c.FallbackID() != 1 {
+					t.Errorf("serviços não ordenados ou fallback incorreto: %+v", c.Services)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseCatalog() erro = %v, want contendo %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckExamplesUnknownService(t *testing.T) {
+	c, err := ParseCatalog([]byte(`{"version":"1","services":[{"id":1,"name":"A"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CheckExamples([]IntentExample{{ServiceID: 17, Intent: "novo serviço"}}); err == nil {
+		t.Error("CheckExamples aceitou serviço fora do catálogo")
+	}
+}
@@ -0,0 +1,73 @@
+package data
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// intentsCSV é uma cópia de assets/intents_pre_loaded.csv embutida no binário,
+// já que a imagem final (scratch) não tem acesso ao repositório.
+//
+//go:embed intents_pre_loaded.csv
+var intentsCSV []byte
+
+// IntentExample é uma linha do dataset de intenções (service_id;service_name;intent).
+type IntentExample struct {
+	ServiceID   int
+	ServiceName string
+	Intent      string
+}
+
+// LoadIntentExamples lê o dataset de intenções do arquivo informado.
+// Se path estiver vazio, usa a cópia embutida no binário.
+func LoadIntentExamples(path string) ([]IntentExample, error) {
+	if path == "" {
+		return parseIntentExamples(bytes.NewReader(intentsCSV))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir dataset de intenções: %w", err)
+	}
+	defer f.Close()
+
+	return parseIntentExamples(f)
+}
+
+func parseIntentExamples(r io.Reader) ([]IntentExample, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = ';'
+	reader.FieldsPerRecord = 3
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler dataset de intenções: %w", err)
+	}
+
+	examples := make([]IntentExample, 0, len(records))
+	for i, record := range records {
+		// Ignora o cabeçalho
+		if i == 0 && record[0] == "service_id" {
+			continue
+		}
+
+		serviceID, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("service_id inválido na linha %d: %w", i+1, err)
+		}
+
+		examples = append(examples, IntentExample{
+			ServiceID:   serviceID,
+			ServiceName: strings.TrimSpace(record[1]),
+			Intent:      strings.TrimSpace(record[2]),
+		})
+	}
+
+	return examples, nil
+}
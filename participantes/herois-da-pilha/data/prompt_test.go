@@ -0,0 +1,47 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPromptTemplate(t *testing.T) {
+	embedded, err := LoadPromptTemplate("")
+	if err != nil {
+		t.Fatalf("template embutido: %v", err)
+	}
+	for _, name := range []string{"system", "user"} {
+		if embedded.Lookup(name) == nil {
+			t.Errorf("template embutido não define %q", name)
+		}
+	}
+
+	dir := t.TempDir()
+	write := func(name, text string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	valid := write("valido.tmpl", `{{define "system"}}S{{end}}{{define "user"}}{{.Intent}}{{end}}`)
+	if _, err := LoadPromptTemplate(valid); err != nil {
+		t.Errorf("template válido: %v", err)
+	}
+
+	tests := []struct {
+		name, path, wantErr string
+	}{
+		{"sintaxe inválida", write("sintaxe.tmpl", `{{define "system"}}{{range .Services}}{{end}}`), "interpretar"},
+		{"sem user", write("sem-user.tmpl", `{{define "system"}}S{{end}}`), `"user"`},
+		{"arquivo inexistente", filepath.Join(dir, "nao-existe.tmpl"), "ler"},
+	}
+	for _, tt := range tests {
+		if _, err := LoadPromptTemplate(tt.path); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: err = %v, want erro com %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -2,4 +2,15 @@ module herois-da-pilha
 
 go 1.23.3
 
-require github.com/sashabaranov/go-openai v1.41.2
+require (
+	github.com/sashabaranov/go-openai v1.41.2
+	golang.org/x/text v0.23.0
+	google.golang.org/grpc v1.73.0
+	google.golang.org/protobuf v1.36.6
+)
+
+require (
+	golang.org/x/net v0.38.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250324211829-b45e905df463 // indirect
+)
@@ -0,0 +1,267 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"herois-da-pilha/cache"
+	"herois-da-pilha/grpcapi/ivrpb"
+	"herois-da-pilha/handler"
+	"herois-da-pilha/service"
+	"herois-da-pilha/util"
+)
+
+// newFinder cria um FinderService determinístico (sem IA) sobre os recursos
+// embutidos. REST e gRPC usam instâncias separadas para que nenhuma das
+// APIs responda do cache preenchido pela outra.
+func newFinder(t *testing.T, res *service.Resources) *service.FinderService {
+	t.Helper()
+	cascade := &service.CascadeClassifier{Stages: []service.Stage{
+		{Name: service.StageExact, Classifier: service.NewExactClassifier(res.Examples), MinScore: 1},
+		{Name: service.StageFuzzy, Classifier: service.NewFuzzyClassifier(res.Examples), MinScore: 0.9},
+		{Name: service.StageLocal, Classifier: service.NewLocalClassifier(res.Examples), MinScore: 0.5},
+	}}
+	s := service.NewFinderServiceWith(res, cascade, cache.NewLRU(cache.Options[util.FindServiceResponse]{}))
+	t.Cleanup(func() { s.Close(context.Background()) })
+	return s
+}
+
+// setup sobe a API REST (httptest) e a gRPC (bufconn).
+func setup(t *testing.T, maxBatch int) (*httptest.Server, ivrpb.IVRServiceClient, []string) {
+	t.Helper()
+	// As duas instâncias aceitam os tokens de esclarecimento uma da outra
+	t.Setenv("CLARIFY_TOKEN_KEY", "conformance")
+	res, err := service.LoadResources("", "", "", 12)
+	if err != nil {
+		t.Fatalf("LoadResources: %v", err)
+	}
+
+	api := &handler.APIHandler{FinderService: newFinder(t, res), MaxBatchSize: maxBatch}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/find-service", api.FindServiceHandler)
+	mux.HandleFunc("/api/find-service/batch", api.BatchFindServiceHandler)
+	mux.HandleFunc("/api/sessions/", api.SessionHandler)
+	rest := httptest.NewServer(mux)
+	t.Cleanup(rest.Close)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(newFinder(t, res), maxBatch)
+	go srv.Serve(lis)
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	intents := []string{"xyzzy plugh"} // sem correspondência: compara também as falhas
+	for _, ex := range res.Examples {
+		intents = append(intents, ex.Intent)
+	}
+	return rest, ivrpb.NewIVRServiceClient(conn), intents
+}
+
+func postJSON(t *testing.T, url string, body, out any) {
+	t.Helper()
+	raw, _ := json.Marshal(body)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("POST %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("decode %s: %v", url, err)
+	}
+}
+
+// fromProto converte a resposta gRPC para o formato do REST.
+func fromProto(r *ivrpb.FindServiceResponse) util.FindServiceResponse {
+	resp := util.FindServiceResponse{
+		Success:    r.GetSuccess(),
+		Data:       util.ServiceData{ServiceID: int(r.GetServiceId()), ServiceName: r.GetServiceName()},
+		Error:      r.GetError(),
+		Confidence: r.GetConfidence(),
+		Stage:      r.GetStage(),
+	}
+	if r.GetErrorCode() != ivrpb.ErrorCode_ERROR_CODE_UNSPECIFIED {
+		resp.ErrorCode = util.ErrorCode(r.GetErrorCode().String())
+	}
+	resp.Candidates = candidatesFromProto(r.GetCandidates())
+	if c := r.GetClarification(); c != nil {
+		resp.Clarification = &util.Clarification{
+			Question: c.GetQuestion(),
+			Options:  candidatesFromProto(c.GetOptions()),
+			Token:    c.GetToken(),
+		}
+	}
+	return resp
+}
+
+func candidatesFromProto(candidates []*ivrpb.ServiceCandidate) []util.ServiceCandidate {
+	var out []util.ServiceCandidate
+	for _, c := range candidates {
+		out = append(out, util.ServiceCandidate{
+			ServiceID: int(c.GetServiceId()), ServiceName: c.GetServiceName(), Confidence: c.GetConfidence(),
+		})
+	}
+	return out
+}
+
+func TestConformanceFindService(t *testing.T) {
+	rest, client, intents := setup(t, 100)
+
+	for _, topK := range []int{0, 3} {
+		for _, clarify := range []bool{false, true} {
+			for _, intent := range intents {
+				var want util.FindServiceResponse
+				postJSON(t, rest.URL+"/api/find-service", util.FindServiceRequest{Intent: intent, TopK: topK, Clarify: clarify}, &want)
+
+				got, err := client.FindService(context.Background(), &ivrpb.FindServiceRequest{Intent: intent, TopK: int32(topK), Clarify: clarify})
+				if err != nil {
+					t.Fatalf("FindService(%q): %v", intent, err)
+				}
+				if !reflect.DeepEqual(fromProto(got), want) {
+					t.Errorf("top_k=%d clarify=%v %q:\n gRPC %+v\n REST %+v", topK, clarify, intent, fromProto(got), want)
+				}
+			}
+		}
+	}
+}
+
+func TestConformanceClarification(t *testing.T) {
+	rest, client, intents := setup(t, 100)
+
+	// Token de um pedido de esclarecimento real (opções 8, 7 e 9)
+	var ambiguous util.FindServiceResponse
+	postJSON(t, rest.URL+"/api/find-service", util.FindServiceRequest{Intent: "cartão", Clarify: true}, &ambiguous)
+	if ambiguous.Clarification == nil {
+		t.Fatalf("sem pedido de esclarecimento: %+v", ambiguous)
+	}
+	token := ambiguous.Clarification.Token
+
+	answers := append([]string{"a segunda", "opção 1", "segunda via"}, intents[:10]...)
+	for _, tok := range []string{token, "inválido"} {
+		for _, answer := range answers {
+			var want util.FindServiceResponse
+			postJSON(t, rest.URL+"/api/find-service", util.FindServiceRequest{Intent: answer, TopK: 3, Clarify: true, ClarificationToken: tok}, &want)
+
+			got, err := client.FindService(context.Background(), &ivrpb.FindServiceRequest{Intent: answer, TopK: 3, Clarify: true, ClarificationToken: tok})
+			if err != nil {
+				t.Fatalf("FindService(%q): %v", answer, err)
+			}
+			if !reflect.DeepEqual(fromProto(got), want) {
+				t.Errorf("token=%q %q:\n gRPC %+v\n REST %+v", tok, answer, fromProto(got), want)
+			}
+		}
+	}
+}
+
+func TestConformanceBatchFindService(t *testing.T) {
+	rest, client, intents := setup(t, 1000)
+
+	req := &ivrpb.BatchFindServiceRequest{TopK: 2}
+	restReq := util.FindServiceBatchRequest{TopK: 2}
+	for i, intent := range append(intents, "") {
+		id := fmt.Sprint(i)
+		req.Items = append(req.Items, &ivrpb.BatchItem{Id: id, Intent: intent})
+		restReq.Items = append(restReq.Items, util.BatchItem{ID: id, Intent: intent})
+	}
+
+	var want util.FindServiceBatchResponse
+	postJSON(t, rest.URL+"/api/find-service/batch", restReq, &want)
+	got, err := client.BatchFindService(context.Background(), req)
+	if err != nil {
+		t.Fatalf("BatchFindService: %v", err)
+	}
+
+	if len(got.GetResults()) != len(want.Results) {
+		t.Fatalf("gRPC retornou %d resultados, REST %d", len(got.GetResults()), len(want.Results))
+	}
+	for i, r := range got.GetResults() {
+		result := util.BatchResult{ID: r.GetId(), FindServiceResponse: fromProto(r.GetResult())}
+		if !reflect.DeepEqual(result, want.Results[i]) {
+			t.Errorf("item %d:\n gRPC %+v\n REST %+v", i, result, want.Results[i])
+		}
+	}
+}
+
+func TestBatchValidation(t *testing.T) {
+	_, client, _ := setup(t, 2)
+
+	tests := []struct {
+		name  string
+		items []*ivrpb.BatchItem
+		want  codes.Code
+	}{
+		{"vazio", nil, codes.InvalidArgument},
+		{"sem id", []*ivrpb.BatchItem{{Intent: "a"}}, codes.InvalidArgument},
+		{"id duplicado", []*ivrpb.BatchItem{{Id: "1", Intent: "a"}, {Id: "1", Intent: "b"}}, codes.InvalidArgument},
+		{"grande demais", []*ivrpb.BatchItem{{Id: "1"}, {Id: "2"}, {Id: "3"}}, codes.ResourceExhausted},
+	}
+	for _, tt := range tests {
+		_, err := client.BatchFindService(context.Background(), &ivrpb.BatchFindServiceRequest{Items: tt.items})
+		if got := status.Code(err); got != tt.want {
+			t.Errorf("%s: código = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConformanceEndSession(t *testing.T) {
+	rest, client, _ := setup(t, 100)
+	turns := []string{"quero cancelar", "não, é o seguro"}
+
+	// REST: DELETE /api/sessions/{id}
+	for _, intent := range turns {
+		var resp util.FindServiceResponse
+		postJSON(t, rest.URL+"/api/find-service", util.FindServiceRequest{Intent: intent, SessionID: "rest"}, &resp)
+	}
+	for _, want := range []struct {
+		status int
+		body   util.EndSessionResponse
+	}{
+		{http.StatusOK, util.EndSessionResponse{Success: true, Turns: len(turns)}},
+		{http.StatusNotFound, util.EndSessionResponse{Error: "sessão não encontrada"}},
+	} {
+		req, _ := http.NewRequest(http.MethodDelete, rest.URL+"/api/sessions/rest", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body util.EndSessionResponse
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != want.status || body != want.body {
+			t.Errorf("DELETE: %d %+v (%v), want %d %+v", resp.StatusCode, body, err, want.status, want.body)
+		}
+	}
+
+	// gRPC: EndSession
+	for _, intent := range turns {
+		if _, err := client.FindService(context.Background(), &ivrpb.FindServiceRequest{Intent: intent, SessionId: "grpc"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := client.EndSession(context.Background(), &ivrpb.EndSessionRequest{SessionId: "grpc"})
+	if err != nil || got.GetTurns() != int32(len(turns)) {
+		t.Errorf("EndSession = %v, %v; want %d turnos", got, err, len(turns))
+	}
+	if _, err := client.EndSession(context.Background(), &ivrpb.EndSessionRequest{SessionId: "grpc"}); status.Code(err) != codes.NotFound {
+		t.Errorf("EndSession repetido: %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,1067 @@
+// API gRPC do serviço de classificação de intenções da URA. Espelha os
+// endpoints REST /api/find-service, /api/find-service/batch e /api/readyz,
+// servida pelo mesmo FinderService.
+//
+// Para regenerar o código Go (grpcapi/ivrpb), a partir da raiz do serviço:
+//
+//	protoc -I proto --go_out=. --go_opt=module=herois-da-pilha \
+//	  --go-grpc_out=. --go-grpc_opt=module=herois-da-pilha ivr/v1/ivr.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: ivr/v1/ivr.proto
+
+package ivrpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ErrorCode espelha os códigos de erro do REST (docs/error_codes.md); os
+// nomes são os mesmos do campo "error_code" do JSON.
+type ErrorCode int32
+
+const (
+	ErrorCode_ERROR_CODE_UNSPECIFIED ErrorCode = 0
+	ErrorCode_INVALID_BODY           ErrorCode = 1
+	ErrorCode_METHOD_NOT_ALLOWED     ErrorCode = 2
+	ErrorCode_NO_MATCH               ErrorCode = 3
+	ErrorCode_UPSTREAM_TIMEOUT       ErrorCode = 4
+	ErrorCode_UPSTREAM_ERROR         ErrorCode = 5
+	ErrorCode_INVALID_MODEL_OUTPUT   ErrorCode = 6
+	ErrorCode_SHUTTING_DOWN          ErrorCode = 7
+	ErrorCode_CANCELED               ErrorCode = 8
+	ErrorCode_INTERNAL               ErrorCode = 9
+	ErrorCode_BATCH_TOO_LARGE        ErrorCode = 10
+	ErrorCode_NEEDS_CLARIFICATION    ErrorCode = 11
+)
+
+// Enum value maps for ErrorCode.
+var (
+	ErrorCode_name = map[int32]string{
+		0:  "ERROR_CODE_UNSPECIFIED",
+		1:  "INVALID_BODY",
+		2:  "METHOD_NOT_ALLOWED",
+		3:  "NO_MATCH",
+		4:  "UPSTREAM_TIMEOUT",
+		5:  "UPSTREAM_ERROR",
+		6:  "INVALID_MODEL_OUTPUT",
+		7:  "SHUTTING_DOWN",
+		8:  "CANCELED",
+		9:  "INTERNAL",
+		10: "BATCH_TOO_LARGE",
+		11: "NEEDS_CLARIFICATION",
+	}
+	ErrorCode_value = map[string]int32{
+		"ERROR_CODE_UNSPECIFIED": 0,
+		"INVALID_BODY":           1,
+		"METHOD_NOT_ALLOWED":     2,
+		"NO_MATCH":               3,
+		"UPSTREAM_TIMEOUT":       4,
+		"UPSTREAM_ERROR":         5,
+		"INVALID_MODEL_OUTPUT":   6,
+		"SHUTTING_DOWN":          7,
+		"CANCELED":               8,
+		"INTERNAL":               9,
+		"BATCH_TOO_LARGE":        10,
+		"NEEDS_CLARIFICATION":    11,
+	}
+)
+
+func (x ErrorCode) Enum() *ErrorCode {
+	p := new(ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_ivr_v1_ivr_proto_enumTypes[0].Descriptor()
+}
+
+func (ErrorCode) Type() protoreflect.EnumType {
+	return &file_ivr_v1_ivr_proto_enumTypes[0]
+}
+
+func (x ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCode.Descriptor instead.
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{0}
+}
+
+type FindServiceRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Intent string                 `protobuf:"bytes,1,opt,name=intent,proto3" json:"intent,omitempty"`
+	// top_k > 0 ativa o modo detalhado (confidence, candidates e stage).
+	TopK int32 `protobuf:"varint,2,opt,name=top_k,json=topK,proto3" json:"top_k,omitempty"`
+	// ensemble pede ou dispensa a votação entre modelos; ausente segue o
+	// padrão da implantação.
+	Ensemble *bool `protobuf:"varint,3,opt,name=ensemble,proto3,oneof" json:"ensemble,omitempty"`
+	// session_id identifica a ligação: os turnos anteriores da sessão são
+	// usados como contexto e a intenção atual é acrescentada a ela.
+	SessionId string `protobuf:"bytes,4,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// history são os turnos anteriores, do mais antigo ao mais recente; se
+	// presente, substitui o histórico da sessão.
+	History []string `protobuf:"bytes,5,rep,name=history,proto3" json:"history,omitempty"`
+	// clarify pede uma pergunta de esclarecimento (NEEDS_CLARIFICATION) em vez
+	// de NO_MATCH quando a intenção é ambígua.
+	Clarify bool `protobuf:"varint,6,opt,name=clarify,proto3" json:"clarify,omitempty"`
+	// clarification_token indica que intent é a resposta do cliente à pergunta
+	// de esclarecimento que retornou o token.
+	ClarificationToken string `protobuf:"bytes,7,opt,name=clarification_token,json=clarificationToken,proto3" json:"clarification_token,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *FindServiceRequest) Reset() {
+	*x = FindServiceRequest{}
+	mi := &file_ivr_v1_ivr_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindServiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindServiceRequest) ProtoMessage() {}
+
+func (x *FindServiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ivr_v1_ivr_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindServiceRequest.ProtoReflect.Descriptor instead.
+func (*FindServiceRequest) Descriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FindServiceRequest) GetIntent() string {
+	if x != nil {
+		return x.Intent
+	}
+	return ""
+}
+
+func (x *FindServiceRequest) GetTopK() int32 {
+	if x != nil {
+		return x.TopK
+	}
+	return 0
+}
+
+func (x *FindServiceRequest) GetEnsemble() bool {
+	if x != nil && x.Ensemble != nil {
+		return *x.Ensemble
+	}
+	return false
+}
+
+func (x *FindServiceRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *FindServiceRequest) GetHistory() []string {
+	if x != nil {
+		return x.History
+	}
+	return nil
+}
+
+func (x *FindServiceRequest) GetClarify() bool {
+	if x != nil {
+		return x.Clarify
+	}
+	return false
+}
+
+func (x *FindServiceRequest) GetClarificationToken() string {
+	if x != nil {
+		return x.ClarificationToken
+	}
+	return ""
+}
+
+type ServiceCandidate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServiceId     int32                  `protobuf:"varint,1,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
+	ServiceName   string                 `protobuf:"bytes,2,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,3,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServiceCandidate) Reset() {
+	*x = ServiceCandidate{}
+	mi := &file_ivr_v1_ivr_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceCandidate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceCandidate) ProtoMessage() {}
+
+func (x *ServiceCandidate) ProtoReflect() protoreflect.Message {
+	mi := &file_ivr_v1_ivr_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceCandidate.ProtoReflect.Descriptor instead.
+func (*ServiceCandidate) Descriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ServiceCandidate) GetServiceId() int32 {
+	if x != nil {
+		return x.ServiceId
+	}
+	return 0
+}
+
+func (x *ServiceCandidate) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *ServiceCandidate) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+type FindServiceResponse struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Success     bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	ServiceId   int32                  `protobuf:"varint,2,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
+	ServiceName string                 `protobuf:"bytes,3,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	Error       string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	ErrorCode   ErrorCode              `protobuf:"varint,5,opt,name=error_code,json=errorCode,proto3,enum=ivr.v1.ErrorCode" json:"error_code,omitempty"`
+	Confidence  float64                `protobuf:"fixed64,6,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Candidates  []*ServiceCandidate    `protobuf:"bytes,7,rep,name=candidates,proto3" json:"candidates,omitempty"`
+	Stage       string                 `protobuf:"bytes,8,opt,name=stage,proto3" json:"stage,omitempty"`
+	// Presente apenas com error_code NEEDS_CLARIFICATION.
+	Clarification *Clarification `protobuf:"bytes,9,opt,name=clarification,proto3" json:"clarification,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindServiceResponse) Reset() {
+	*x = FindServiceResponse{}
+	mi := &file_ivr_v1_ivr_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindServiceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindServiceResponse) ProtoMessage() {}
+
+func (x *FindServiceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ivr_v1_ivr_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindServiceResponse.ProtoReflect.Descriptor instead.
+func (*FindServiceResponse) Descriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FindServiceResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *FindServiceResponse) GetServiceId() int32 {
+	if x != nil {
+		return x.ServiceId
+	}
+	return 0
+}
+
+func (x *FindServiceResponse) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *FindServiceResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *FindServiceResponse) GetErrorCode() ErrorCode {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ErrorCode_ERROR_CODE_UNSPECIFIED
+}
+
+func (x *FindServiceResponse) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *FindServiceResponse) GetCandidates() []*ServiceCandidate {
+	if x != nil {
+		return x.Candidates
+	}
+	return nil
+}
+
+func (x *FindServiceResponse) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *FindServiceResponse) GetClarification() *Clarification {
+	if x != nil {
+		return x.Clarification
+	}
+	return nil
+}
+
+// Clarification é a pergunta a ser feita ao cliente e os serviços plausíveis.
+type Clarification struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Question      string                 `protobuf:"bytes,1,opt,name=question,proto3" json:"question,omitempty"`
+	Options       []*ServiceCandidate    `protobuf:"bytes,2,rep,name=options,proto3" json:"options,omitempty"`
+	Token         string                 `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Clarification) Reset() {
+	*x = Clarification{}
+	mi := &file_ivr_v1_ivr_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Clarification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Clarification) ProtoMessage() {}
+
+func (x *Clarification) ProtoReflect() protoreflect.Message {
+	mi := &file_ivr_v1_ivr_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Clarification.ProtoReflect.Descriptor instead.
+func (*Clarification) Descriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Clarification) GetQuestion() string {
+	if x != nil {
+		return x.Question
+	}
+	return ""
+}
+
+func (x *Clarification) GetOptions() []*ServiceCandidate {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *Clarification) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type BatchItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Intent        string                 `protobuf:"bytes,2,opt,name=intent,proto3" json:"intent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchItem) Reset() {
+	*x = BatchItem{}
+	mi := &file_ivr_v1_ivr_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchItem) ProtoMessage() {}
+
+func (x *BatchItem) ProtoReflect() protoreflect.Message {
+	mi := &file_ivr_v1_ivr_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchItem.ProtoReflect.Descriptor instead.
+func (*BatchItem) Descriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchItem) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BatchItem) GetIntent() string {
+	if x != nil {
+		return x.Intent
+	}
+	return ""
+}
+
+type BatchFindServiceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*BatchItem           `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	TopK          int32                  `protobuf:"varint,2,opt,name=top_k,json=topK,proto3" json:"top_k,omitempty"`
+	Ensemble      *bool                  `protobuf:"varint,3,opt,name=ensemble,proto3,oneof" json:"ensemble,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchFindServiceRequest) Reset() {
+	*x = BatchFindServiceRequest{}
+	mi := &file_ivr_v1_ivr_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchFindServiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchFindServiceRequest) ProtoMessage() {}
+
+func (x *BatchFindServiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ivr_v1_ivr_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchFindServiceRequest.ProtoReflect.Descriptor instead.
+func (*BatchFindServiceRequest) Descriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchFindServiceRequest) GetItems() []*BatchItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *BatchFindServiceRequest) GetTopK() int32 {
+	if x != nil {
+		return x.TopK
+	}
+	return 0
+}
+
+func (x *BatchFindServiceRequest) GetEnsemble() bool {
+	if x != nil && x.Ensemble != nil {
+		return *x.Ensemble
+	}
+	return false
+}
+
+type BatchResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Result        *FindServiceResponse   `protobuf:"bytes,2,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchResult) Reset() {
+	*x = BatchResult{}
+	mi := &file_ivr_v1_ivr_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResult) ProtoMessage() {}
+
+func (x *BatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_ivr_v1_ivr_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResult.ProtoReflect.Descriptor instead.
+func (*BatchResult) Descriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BatchResult) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BatchResult) GetResult() *FindServiceResponse {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+type BatchFindServiceResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Um resultado por item, na ordem dos itens.
+	Results       []*BatchResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchFindServiceResponse) Reset() {
+	*x = BatchFindServiceResponse{}
+	mi := &file_ivr_v1_ivr_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchFindServiceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchFindServiceResponse) ProtoMessage() {}
+
+func (x *BatchFindServiceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ivr_v1_ivr_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchFindServiceResponse.ProtoReflect.Descriptor instead.
+func (*BatchFindServiceResponse) Descriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BatchFindServiceResponse) GetResults() []*BatchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type EndSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EndSessionRequest) Reset() {
+	*x = EndSessionRequest{}
+	mi := &file_ivr_v1_ivr_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EndSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EndSessionRequest) ProtoMessage() {}
+
+func (x *EndSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ivr_v1_ivr_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EndSessionRequest.ProtoReflect.Descriptor instead.
+func (*EndSessionRequest) Descriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *EndSessionRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type EndSessionResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Turnos que a sessão tinha.
+	Turns         int32 `protobuf:"varint,1,opt,name=turns,proto3" json:"turns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EndSessionResponse) Reset() {
+	*x = EndSessionResponse{}
+	mi := &file_ivr_v1_ivr_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EndSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EndSessionResponse) ProtoMessage() {}
+
+func (x *EndSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ivr_v1_ivr_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EndSessionResponse.ProtoReflect.Descriptor instead.
+func (*EndSessionResponse) Descriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *EndSessionResponse) GetTurns() int32 {
+	if x != nil {
+		return x.Turns
+	}
+	return 0
+}
+
+type HealthRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthRequest) Reset() {
+	*x = HealthRequest{}
+	mi := &file_ivr_v1_ivr_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthRequest) ProtoMessage() {}
+
+func (x *HealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ivr_v1_ivr_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{10}
+}
+
+type ComponentStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Detail        string                 `protobuf:"bytes,2,opt,name=detail,proto3" json:"detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ComponentStatus) Reset() {
+	*x = ComponentStatus{}
+	mi := &file_ivr_v1_ivr_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComponentStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComponentStatus) ProtoMessage() {}
+
+func (x *ComponentStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_ivr_v1_ivr_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComponentStatus.ProtoReflect.Descriptor instead.
+func (*ComponentStatus) Descriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ComponentStatus) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ComponentStatus) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+type HealthResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "ok" ou "not_ready", como em /api/readyz.
+	Status        string                      `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	ConfigVersion string                      `protobuf:"bytes,2,opt,name=config_version,json=configVersion,proto3" json:"config_version,omitempty"`
+	Components    map[string]*ComponentStatus `protobuf:"bytes,3,rep,name=components,proto3" json:"components,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthResponse) Reset() {
+	*x = HealthResponse{}
+	mi := &file_ivr_v1_ivr_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthResponse) ProtoMessage() {}
+
+func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ivr_v1_ivr_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return file_ivr_v1_ivr_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *HealthResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetConfigVersion() string {
+	if x != nil {
+		return x.ConfigVersion
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetComponents() map[string]*ComponentStatus {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+var File_ivr_v1_ivr_proto protoreflect.FileDescriptor
+
+const file_ivr_v1_ivr_proto_rawDesc = "" +
+	"\n" +
+	"\x10ivr/v1/ivr.proto\x12\x06ivr.v1\"\xf3\x01\n" +
+	"\x12FindServiceRequest\x12\x16\n" +
+	"\x06intent\x18\x01 \x01(\tR\x06intent\x12\x13\n" +
+	"\x05top_k\x18\x02 \x01(\x05R\x04topK\x12\x1f\n" +
+	"\bensemble\x18\x03 \x01(\bH\x00R\bensemble\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x04 \x01(\tR\tsessionId\x12\x18\n" +
+	"\ahistory\x18\x05 \x03(\tR\ahistory\x12\x18\n" +
+	"\aclarify\x18\x06 \x01(\bR\aclarify\x12/\n" +
+	"\x13clarification_token\x18\a \x01(\tR\x12clarificationTokenB\v\n" +
+	"\t_ensemble\"t\n" +
+	"\x10ServiceCandidate\x12\x1d\n" +
+	"\n" +
+	"service_id\x18\x01 \x01(\x05R\tserviceId\x12!\n" +
+	"\fservice_name\x18\x02 \x01(\tR\vserviceName\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x03 \x01(\x01R\n" +
+	"confidence\"\xe6\x02\n" +
+	"\x13FindServiceResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x1d\n" +
+	"\n" +
+	"service_id\x18\x02 \x01(\x05R\tserviceId\x12!\n" +
+	"\fservice_name\x18\x03 \x01(\tR\vserviceName\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x120\n" +
+	"\n" +
+	"error_code\x18\x05 \x01(\x0e2\x11.ivr.v1.ErrorCodeR\terrorCode\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x06 \x01(\x01R\n" +
+	"confidence\x128\n" +
+	"\n" +
+	"candidates\x18\a \x03(\v2\x18.ivr.v1.ServiceCandidateR\n" +
+	"candidates\x12\x14\n" +
+	"\x05stage\x18\b \x01(\tR\x05stage\x12;\n" +
+	"\rclarification\x18\t \x01(\v2\x15.ivr.v1.ClarificationR\rclarification\"u\n" +
+	"\rClarification\x12\x1a\n" +
+	"\bquestion\x18\x01 \x01(\tR\bquestion\x122\n" +
+	"\aoptions\x18\x02 \x03(\v2\x18.ivr.v1.ServiceCandidateR\aoptions\x12\x14\n" +
+	"\x05token\x18\x03 \x01(\tR\x05token\"3\n" +
+	"\tBatchItem\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06intent\x18\x02 \x01(\tR\x06intent\"\x85\x01\n" +
+	"\x17BatchFindServiceRequest\x12'\n" +
+	"\x05items\x18\x01 \x03(\v2\x11.ivr.v1.BatchItemR\x05items\x12\x13\n" +
+	"\x05top_k\x18\x02 \x01(\x05R\x04topK\x12\x1f\n" +
+	"\bensemble\x18\x03 \x01(\bH\x00R\bensemble\x88\x01\x01B\v\n" +
+	"\t_ensemble\"R\n" +
+	"\vBatchResult\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x123\n" +
+	"\x06result\x18\x02 \x01(\v2\x1b.ivr.v1.FindServiceResponseR\x06result\"I\n" +
+	"\x18BatchFindServiceResponse\x12-\n" +
+	"\aresults\x18\x01 \x03(\v2\x13.ivr.v1.BatchResultR\aresults\"2\n" +
+	"\x11EndSessionRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"*\n" +
+	"\x12EndSessionResponse\x12\x14\n" +
+	"\x05turns\x18\x01 \x01(\x05R\x05turns\"\x0f\n" +
+	"\rHealthRequest\"A\n" +
+	"\x0fComponentStatus\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x16\n" +
+	"\x06detail\x18\x02 \x01(\tR\x06detail\"\xef\x01\n" +
+	"\x0eHealthResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12%\n" +
+	"\x0econfig_version\x18\x02 \x01(\tR\rconfigVersion\x12F\n" +
+	"\n" +
+	"components\x18\x03 \x03(\v2&.ivr.v1.HealthResponse.ComponentsEntryR\n" +
+	"components\x1aV\n" +
+	"\x0fComponentsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12-\n" +
+	"\x05value\x18\x02 \x01(\v2\x17.ivr.v1.ComponentStatusR\x05value:\x028\x01*\x80\x02\n" +
+	"\tErrorCode\x12\x1a\n" +
+	"\x16ERROR_CODE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fINVALID_BODY\x10\x01\x12\x16\n" +
+	"\x12METHOD_NOT_ALLOWED\x10\x02\x12\f\n" +
+	"\bNO_MATCH\x10\x03\x12\x14\n" +
+	"\x10UPSTREAM_TIMEOUT\x10\x04\x12\x12\n" +
+	"\x0eUPSTREAM_ERROR\x10\x05\x12\x18\n" +
+	"\x14INVALID_MODEL_OUTPUT\x10\x06\x12\x11\n" +
+	"\rSHUTTING_DOWN\x10\a\x12\f\n" +
+	"\bCANCELED\x10\b\x12\f\n" +
+	"\bINTERNAL\x10\t\x12\x13\n" +
+	"\x0fBATCH_TOO_LARGE\x10\n" +
+	"\x12\x17\n" +
+	"\x13NEEDS_CLARIFICATION\x10\v2\xa9\x02\n" +
+	"\n" +
+	"IVRService\x12F\n" +
+	"\vFindService\x12\x1a.ivr.v1.FindServiceRequest\x1a\x1b.ivr.v1.FindServiceResponse\x12U\n" +
+	"\x10BatchFindService\x12\x1f.ivr.v1.BatchFindServiceRequest\x1a .ivr.v1.BatchFindServiceResponse\x12C\n" +
+	"\n" +
+	"EndSession\x12\x19.ivr.v1.EndSessionRequest\x1a\x1a.ivr.v1.EndSessionResponse\x127\n" +
+	"\x06Health\x12\x15.ivr.v1.HealthRequest\x1a\x16.ivr.v1.HealthResponseB\x1fZ\x1dherois-da-pilha/grpcapi/ivrpbb\x06proto3"
+
+var (
+	file_ivr_v1_ivr_proto_rawDescOnce sync.Once
+	file_ivr_v1_ivr_proto_rawDescData []byte
+)
+
+func file_ivr_v1_ivr_proto_rawDescGZIP() []byte {
+	file_ivr_v1_ivr_proto_rawDescOnce.Do(func() {
+		file_ivr_v1_ivr_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ivr_v1_ivr_proto_rawDesc), len(file_ivr_v1_ivr_proto_rawDesc)))
+	})
+	return file_ivr_v1_ivr_proto_rawDescData
+}
+
+var file_ivr_v1_ivr_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_ivr_v1_ivr_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_ivr_v1_ivr_proto_goTypes = []any{
+	(ErrorCode)(0),                   // 0: ivr.v1.ErrorCode
+	(*FindServiceRequest)(nil),       // 1: ivr.v1.FindServiceRequest
+	(*ServiceCandidate)(nil),         // 2: ivr.v1.ServiceCandidate
+	(*FindServiceResponse)(nil),      // 3: ivr.v1.FindServiceResponse
+	(*Clarification)(nil),            // 4: ivr.v1.Clarification
+	(*BatchItem)(nil),                // 5: ivr.v1.BatchItem
+	(*BatchFindServiceRequest)(nil),  // 6: ivr.v1.BatchFindServiceRequest
+	(*BatchResult)(nil),              // 7: ivr.v1.BatchResult
+	(*BatchFindServiceResponse)(nil), // 8: ivr.v1.BatchFindServiceResponse
+	(*EndSessionRequest)(nil),        // 9: ivr.v1.EndSessionRequest
+	(*EndSessionResponse)(nil),       // 10: ivr.v1.EndSessionResponse
+	(*HealthRequest)(nil),            // 11: ivr.v1.HealthRequest
+	(*ComponentStatus)(nil),          // 12: ivr.v1.ComponentStatus
+	(*HealthResponse)(nil),           // 13: ivr.v1.HealthResponse
+	nil,                              // 14: ivr.v1.HealthResponse.ComponentsEntry
+}
+var file_ivr_v1_ivr_proto_depIdxs = []int32{
+	0,  // 0: ivr.v1.FindServiceResponse.error_code:type_name -> ivr.v1.ErrorCode
+	2,  // 1: ivr.v1.FindServiceResponse.candidates:type_name -> ivr.v1.ServiceCandidate
+	4,  // 2: ivr.v1.FindServiceResponse.clarification:type_name -> ivr.v1.Clarification
+	2,  // 3: ivr.v1.Clarification.options:type_name -> ivr.v1.ServiceCandidate
+	5,  // 4: ivr.v1.BatchFindServiceRequest.items:type_name -> ivr.v1.BatchItem
+	3,  // 5: ivr.v1.BatchResult.result:type_name -> ivr.v1.FindServiceResponse
+	7,  // 6: ivr.v1.BatchFindServiceResponse.results:type_name -> ivr.v1.BatchResult
+	14, // 7: ivr.v1.HealthResponse.components:type_name -> ivr.v1.HealthResponse.ComponentsEntry
+	12, // 8: ivr.v1.HealthResponse.ComponentsEntry.value:type_name -> ivr.v1.ComponentStatus
+	1,  // 9: ivr.v1.IVRService.FindService:input_type -> ivr.v1.FindServiceRequest
+	6,  // 10: ivr.v1.IVRService.BatchFindService:input_type -> ivr.v1.BatchFindServiceRequest
+	9,  // 11: ivr.v1.IVRService.EndSession:input_type -> ivr.v1.EndSessionRequest
+	11, // 12: ivr.v1.IVRService.Health:input_type -> ivr.v1.HealthRequest
+	3,  // 13: ivr.v1.IVRService.FindService:output_type -> ivr.v1.FindServiceResponse
+	8,  // 14: ivr.v1.IVRService.BatchFindService:output_type -> ivr.v1.BatchFindServiceResponse
+	10, // 15: ivr.v1.IVRService.EndSession:output_type -> ivr.v1.EndSessionResponse
+	13, // 16: ivr.v1.IVRService.Health:output_type -> ivr.v1.HealthResponse
+	13, // [13:17] is the sub-list for method output_type
+	9,  // [9:13] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_ivr_v1_ivr_proto_init() }
+func file_ivr_v1_ivr_proto_init() {
+	if File_ivr_v1_ivr_proto != nil {
+		return
+	}
+	file_ivr_v1_ivr_proto_msgTypes[0].OneofWrappers = []any{}
+	file_ivr_v1_ivr_proto_msgTypes[5].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ivr_v1_ivr_proto_rawDesc), len(file_ivr_v1_ivr_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ivr_v1_ivr_proto_goTypes,
+		DependencyIndexes: file_ivr_v1_ivr_proto_depIdxs,
+		EnumInfos:         file_ivr_v1_ivr_proto_enumTypes,
+		MessageInfos:      file_ivr_v1_ivr_proto_msgTypes,
+	}.Build()
+	File_ivr_v1_ivr_proto = out.File
+	file_ivr_v1_ivr_proto_goTypes = nil
+	file_ivr_v1_ivr_proto_depIdxs = nil
+}
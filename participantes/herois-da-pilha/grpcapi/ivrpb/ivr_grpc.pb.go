@@ -0,0 +1,262 @@
+// API gRPC do serviço de classificação de intenções da URA. Espelha os
+// endpoints REST /api/find-service, /api/find-service/batch e /api/readyz,
+// servida pelo mesmo FinderService.
+//
+// Para regenerar o código Go (grpcapi/ivrpb), a partir da raiz do serviço:
+//
+//	protoc -I proto --go_out=. --go_opt=module=herois-da-pilha \
+//	  --go-grpc_out=. --go-grpc_opt=module=herois-da-pilha ivr/v1/ivr.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: ivr/v1/ivr.proto
+
+package ivrpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	IVRService_FindService_FullMethodName      = "/ivr.v1.IVRService/FindService"
+	IVRService_BatchFindService_FullMethodName = "/ivr.v1.IVRService/BatchFindService"
+	IVRService_EndSession_FullMethodName       = "/ivr.v1.IVRService/EndSession"
+	IVRService_Health_FullMethodName           = "/ivr.v1.IVRService/Health"
+)
+
+// IVRServiceClient is the client API for IVRService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IVRServiceClient interface {
+	// FindService classifica uma intenção. Falhas de classificação (NO_MATCH,
+	// UPSTREAM_TIMEOUT etc.) vêm no corpo, como no REST; apenas requisições
+	// inválidas retornam status INVALID_ARGUMENT.
+	FindService(ctx context.Context, in *FindServiceRequest, opts ...grpc.CallOption) (*FindServiceResponse, error)
+	// BatchFindService classifica um lote de intenções identificadas pelo
+	// cliente. Lotes acima do máximo retornam RESOURCE_EXHAUSTED.
+	BatchFindService(ctx context.Context, in *BatchFindServiceRequest, opts ...grpc.CallOption) (*BatchFindServiceResponse, error)
+	// EndSession encerra a sessão de uma ligação (NOT_FOUND se ela não existir
+	// ou já tiver expirado).
+	EndSession(ctx context.Context, in *EndSessionRequest, opts ...grpc.CallOption) (*EndSessionResponse, error)
+	// Health informa se o serviço está pronto e o estado de cada componente.
+	// Balanceadores devem preferir o protocolo padrão grpc.health.v1.Health.
+	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+}
+
+type iVRServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIVRServiceClient(cc grpc.ClientConnInterface) IVRServiceClient {
+	return &iVRServiceClient{cc}
+}
+
+func (c *iVRServiceClient) FindService(ctx context.Context, in *FindServiceRequest, opts ...grpc.CallOption) (*FindServiceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindServiceResponse)
+	err := c.cc.Invoke(ctx, IVRService_FindService_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iVRServiceClient) BatchFindService(ctx context.Context, in *BatchFindServiceRequest, opts ...grpc.CallOption) (*BatchFindServiceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchFindServiceResponse)
+	err := c.cc.Invoke(ctx, IVRService_BatchFindService_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iVRServiceClient) EndSession(ctx context.Context, in *EndSessionRequest, opts ...grpc.CallOption) (*EndSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EndSessionResponse)
+	err := c.cc.Invoke(ctx, IVRService_EndSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iVRServiceClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, IVRService_Health_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IVRServiceServer is the server API for IVRService service.
+// All implementations must embed UnimplementedIVRServiceServer
+// for forward compatibility.
+type IVRServiceServer interface {
+	// FindService classifica uma intenção. Falhas de classificação (NO_MATCH,
+	// UPSTREAM_TIMEOUT etc.) vêm no corpo, como no REST; apenas requisições
+	// inválidas retornam status INVALID_ARGUMENT.
+	FindService(context.Context, *FindServiceRequest) (*FindServiceResponse, error)
+	// BatchFindService classifica um lote de intenções identificadas pelo
+	// cliente. Lotes acima do máximo retornam RESOURCE_EXHAUSTED.
+	BatchFindService(context.Context, *BatchFindServiceRequest) (*BatchFindServiceResponse, error)
+	// EndSession encerra a sessão de uma ligação (NOT_FOUND se ela não existir
+	// ou já tiver expirado).
+	EndSession(context.Context, *EndSessionRequest) (*EndSessionResponse, error)
+	// Health informa se o serviço está pronto e o estado de cada componente.
+	// Balanceadores devem preferir o protocolo padrão grpc.health.v1.Health.
+	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	mustEmbedUnimplementedIVRServiceServer()
+}
+
+// UnimplementedIVRServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIVRServiceServer struct{}
+
+func (UnimplementedIVRServiceServer) FindService(context.Context, *FindServiceRequest) (*FindServiceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindService not implemented")
+}
+func (UnimplementedIVRServiceServer) BatchFindService(context.Context, *BatchFindServiceRequest) (*BatchFindServiceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchFindService not implemented")
+}
+func (UnimplementedIVRServiceServer) EndSession(context.Context, *EndSessionRequest) (*EndSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EndSession not implemented")
+}
+func (UnimplementedIVRServiceServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+func (UnimplementedIVRServiceServer) mustEmbedUnimplementedIVRServiceServer() {}
+func (UnimplementedIVRServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeIVRServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IVRServiceServer will
+// result in compilation errors.
+type UnsafeIVRServiceServer interface {
+	mustEmbedUnimplementedIVRServiceServer()
+}
+
+func RegisterIVRServiceServer(s grpc.ServiceRegistrar, srv IVRServiceServer) {
+	// If the following call pancis, it indicates UnimplementedIVRServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&IVRService_ServiceDesc, srv)
+}
+
+func _IVRService_FindService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IVRServiceServer).FindService(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IVRService_FindService_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IVRServiceServer).FindService(ctx, req.(*FindServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IVRService_BatchFindService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchFindServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IVRServiceServer).BatchFindService(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IVRService_BatchFindService_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IVRServiceServer).BatchFindService(ctx, req.(*BatchFindServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IVRService_EndSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EndSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IVRServiceServer).EndSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IVRService_EndSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IVRServiceServer).EndSession(ctx, req.(*EndSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IVRService_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IVRServiceServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IVRService_Health_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IVRServiceServer).Health(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// IVRService_ServiceDesc is the grpc.ServiceDesc for IVRService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IVRService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ivr.v1.IVRService",
+	HandlerType: (*IVRServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "FindService",
+			Handler:    _IVRService_FindService_Handler,
+		},
+		{
+			MethodName: "BatchFindService",
+			Handler:    _IVRService_BatchFindService_Handler,
+		},
+		{
+			MethodName: "EndSession",
+			Handler:    _IVRService_EndSession_Handler,
+		},
+		{
+			MethodName: "Health",
+			Handler:    _IVRService_Health_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "ivr/v1/ivr.proto",
+}
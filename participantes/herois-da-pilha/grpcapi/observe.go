@@ -0,0 +1,84 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"herois-da-pilha/metrics"
+	"herois-da-pilha/tracing"
+	"herois-da-pilha/util"
+)
+
+var (
+	grpcRequestsTotal = metrics.NewCounterVec("ivr_grpc_requests_total",
+		"Chamadas gRPC, por método e código de status.", "method", "code")
+	grpcRequestDuration = metrics.NewHistogramVec("ivr_grpc_request_duration_seconds",
+		"Latência das chamadas gRPC, por método.", metrics.DefBuckets, "method")
+)
+
+// observe dá às chamadas gRPC o mesmo tratamento do REST: request ID (do
+// metadata x-request-id ou gerado), span continuando o traceparent recebido,
+// uma linha de log por chamada com os campos acumulados e métricas. As
+// sondas do grpc.health.v1 passam direto, como /api/healthz no REST.
+func observe(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	if strings.HasPrefix(info.FullMethod, "/grpc.health.") {
+		return next(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := first(md, "x-request-id")
+	if id == "" || len(id) > 128 {
+		id = util.NewRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs("x-request-id", id))
+	ctx = util.WithRequestID(ctx, id)
+
+	reqLog := &util.RequestLog{}
+	ctx = util.WithRequestLog(ctx, reqLog)
+	ctx = tracing.WithRemoteParent(ctx, first(md, "traceparent"))
+	ctx, span := tracing.Start(ctx, info.FullMethod, tracing.KindServer)
+
+	start := time.Now()
+	resp, err := next(ctx, req)
+	elapsed := time.Since(start)
+	code := status.Code(err)
+
+	span.Set("rpc.system", "grpc")
+	span.Set("rpc.method", info.FullMethod)
+	span.Set("rpc.grpc.status_code", int(code))
+	span.Set("request_id", id)
+	span.SetError(err)
+	span.End()
+
+	method := path.Base(info.FullMethod)
+	grpcRequestsTotal.With(method, code.String()).Inc()
+	grpcRequestDuration.With(method).Observe(elapsed.Seconds())
+
+	attrs := append(reqLog.Attrs(),
+		"method", info.FullMethod,
+		"code", code.String(),
+		"duration_ms", float64(elapsed.Microseconds())/1000,
+	)
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	if traceID := span.TraceID(); traceID != "" {
+		attrs = append(attrs, "trace_id", traceID)
+	}
+	slog.InfoContext(ctx, "grpc", attrs...)
+	return resp, err
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
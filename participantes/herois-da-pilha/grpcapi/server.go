@@ -0,0 +1,212 @@
+// Package grpcapi expõe o FinderService via gRPC (proto/ivr/v1/ivr.proto),
+// numa porta separada da API REST. Além do IVRService, o servidor registra o
+// protocolo de health padrão (grpc.health.v1) e a reflection.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"herois-da-pilha/grpcapi/ivrpb"
+	"herois-da-pilha/service"
+	"herois-da-pilha/util"
+)
+
+// healthInterval é o intervalo entre as atualizações do grpc.health.v1 a
+// partir de FinderService.Readiness.
+const healthInterval = 5 * time.Second
+
+// Server é o servidor gRPC com o IVRService e o health padrão.
+type Server struct {
+	grpc   *grpc.Server
+	health *health.Server
+	finder *service.FinderService
+	done   chan struct{}
+}
+
+// NewServer monta o servidor sobre o FinderService. maxBatchSize limita os
+// itens de BatchFindService, como BATCH_MAX_SIZE na API REST.
+func NewServer(finder *service.FinderService, maxBatchSize int) *Server {
+	s := &Server{
+		grpc:   grpc.NewServer(grpc.ChainUnaryInterceptor(observe)),
+		health: health.NewServer(),
+		finder: finder,
+		done:   make(chan struct{}),
+	}
+	ivrpb.RegisterIVRServiceServer(s.grpc, &ivrService{finder: finder, maxBatchSize: maxBatchSize})
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	reflection.Register(s.grpc)
+	return s
+}
+
+// Serve atende conexões em lis até Shutdown.
+func (s *Server) Serve(lis net.Listener) error {
+	s.updateHealth()
+	go s.healthLoop()
+	return s.grpc.Serve(lis)
+}
+
+// Shutdown marca o serviço como NOT_SERVING e aguarda as chamadas em
+// andamento, interrompendo-as se ctx expirar.
+func (s *Server) Shutdown(ctx context.Context) {
+	close(s.done)
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpc.Stop()
+	}
+}
+
+// healthLoop mantém o grpc.health.v1 em sincronia com a prontidão do serviço.
+func (s *Server) healthLoop() {
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.updateHealth()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *Server) updateHealth() {
+	ctx, cancel := context.WithTimeout(context.Background(), healthInterval)
+	defer cancel()
+
+	serving := healthpb.HealthCheckResponse_NOT_SERVING
+	if ready, _ := s.finder.Readiness(ctx); ready {
+		serving = healthpb.HealthCheckResponse_SERVING
+	}
+	s.health.SetServingStatus("", serving)
+	s.health.SetServingStatus(ivrpb.IVRService_ServiceDesc.ServiceName, serving)
+}
+
+// ivrService implementa ivrpb.IVRServiceServer com as mesmas regras dos
+// handlers REST, para que as duas APIs retornem os mesmos resultados.
+type ivrService struct {
+	ivrpb.UnimplementedIVRServiceServer
+	finder       *service.FinderService
+	maxBatchSize int
+}
+
+// FindService implementa ivrpb.IVRServiceServer.
+func (s *ivrService) FindService(ctx context.Context, req *ivrpb.FindServiceRequest) (*ivrpb.FindServiceResponse, error) {
+	if req.GetIntent() == "" {
+		return nil, status.Error(codes.InvalidArgument, "intent vazio")
+	}
+
+	response := s.finder.FindService(ctx, req.GetIntent(), service.FindOptions{
+		Ensemble:           req.Ensemble,
+		SessionID:          req.GetSessionId(),
+		History:            req.GetHistory(),
+		Clarify:            req.GetClarify(),
+		ClarificationToken: req.GetClarificationToken(),
+	})
+	return toProto(service.ShapeResponse(response, int(req.GetTopK()))), nil
+}
+
+// BatchFindService implementa ivrpb.IVRServiceServer.
+func (s *ivrService) BatchFindService(ctx context.Context, req *ivrpb.BatchFindServiceRequest) (*ivrpb.BatchFindServiceResponse, error) {
+	items := make([]util.BatchItem, len(req.GetItems()))
+	for i, item := range req.GetItems() {
+		items[i] = util.BatchItem{ID: item.GetId(), Intent: item.GetIntent()}
+	}
+	if err := service.ValidateBatch(items, s.maxBatchSize); err != nil {
+		if errors.Is(err, service.ErrBatchTooLarge) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	results := s.finder.FindServiceBatch(ctx, items, service.FindOptions{Ensemble: req.Ensemble})
+	resp := &ivrpb.BatchFindServiceResponse{Results: make([]*ivrpb.BatchResult, len(results))}
+	for i, result := range results {
+		resp.Results[i] = &ivrpb.BatchResult{
+			Id:     result.ID,
+			Result: toProto(service.ShapeResponse(result.FindServiceResponse, int(req.GetTopK()))),
+		}
+	}
+	return resp, nil
+}
+
+// EndSession implementa ivrpb.IVRServiceServer.
+func (s *ivrService) EndSession(_ context.Context, req *ivrpb.EndSessionRequest) (*ivrpb.EndSessionResponse, error) {
+	turns, ok := s.finder.EndSession(req.GetSessionId())
+	if !ok {
+		return nil, status.Error(codes.NotFound, "sessão não encontrada")
+	}
+	return &ivrpb.EndSessionResponse{Turns: int32(turns)}, nil
+}
+
+// Health implementa ivrpb.IVRServiceServer com os mesmos dados de /api/readyz.
+func (s *ivrService) Health(ctx context.Context, _ *ivrpb.HealthRequest) (*ivrpb.HealthResponse, error) {
+	ready, components := s.finder.Readiness(ctx)
+	resp := &ivrpb.HealthResponse{
+		Status:        "ok",
+		ConfigVersion: s.finder.ConfigVersion(),
+		Components:    make(map[string]*ivrpb.ComponentStatus, len(components)),
+	}
+	if !ready {
+		resp.Status = "not_ready"
+	}
+	for name, c := range components {
+		resp.Components[name] = &ivrpb.ComponentStatus{Status: c.Status, Detail: c.Detail}
+	}
+	return resp, nil
+}
+
+// toProto converte a resposta do FinderService para a mensagem gRPC.
+func toProto(r util.FindServiceResponse) *ivrpb.FindServiceResponse {
+	resp := &ivrpb.FindServiceResponse{
+		Success:     r.Success,
+		ServiceId:   int32(r.Data.ServiceID),
+		ServiceName: r.Data.ServiceName,
+		Error:       r.Error,
+		ErrorCode:   ivrpb.ErrorCode(ivrpb.ErrorCode_value[string(r.ErrorCode)]),
+		Confidence:  r.Confidence,
+		Stage:       r.Stage,
+	}
+	resp.Candidates = candidatesToProto(r.Candidates)
+	if r.Clarification != nil {
+		resp.Clarification = &ivrpb.Clarification{
+			Question: r.Clarification.Question,
+			Options:  candidatesToProto(r.Clarification.Options),
+			Token:    r.Clarification.Token,
+		}
+	}
+	if r.ErrorCode != "" && resp.ErrorCode == ivrpb.ErrorCode_ERROR_CODE_UNSPECIFIED {
+		slog.Warn("código de erro sem equivalente no proto", "error_code", r.ErrorCode)
+	}
+	return resp
+}
+
+func candidatesToProto(candidates []util.ServiceCandidate) []*ivrpb.ServiceCandidate {
+	var out []*ivrpb.ServiceCandidate
+	for _, c := range candidates {
+		out = append(out, &ivrpb.ServiceCandidate{
+			ServiceId:   int32(c.ServiceID),
+			ServiceName: c.ServiceName,
+			Confidence:  c.Confidence,
+		})
+	}
+	return out
+}
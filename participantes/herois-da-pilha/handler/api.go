@@ -1,23 +1,53 @@
 package handler
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"herois-da-pilha/service"
+	"herois-da-pilha/tracing"
 	"herois-da-pilha/util"
+	"log/slog"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // APIHandler contém as referências necessárias para os handlers.
 type APIHandler struct {
 	FinderService *service.FinderService
+	// AdminToken autoriza os endpoints administrativos; vazio os desativa.
+	AdminToken string
+	// StrictStatus faz /api/find-service responder erros com o status HTTP
+	// do código de erro (veja statusFor) em vez de sempre 200.
+	StrictStatus bool
+	// MaxBatchSize é o número máximo de itens de /api/find-service/batch.
+	MaxBatchSize int
 }
 
-// NewAPIHandler cria uma nova instância do handler.
-func NewAPIHandler() *APIHandler {
-	return &APIHandler{
+// NewAPIHandler cria uma nova instância do handler. O token administrativo
+// vem de ADMIN_TOKEN e o modo de status estrito de STRICT_HTTP_STATUS
+// (default false, por compatibilidade com clientes que esperam sempre 200). O
+// tamanho máximo dos lotes vem de BATCH_MAX_SIZE (default 100), que deve ser
+// pelo menos 1: com 0, nenhum lote seria aceito e o limite do corpo seria 0.
+func NewAPIHandler() (*APIHandler, error) {
+	maxBatchSize := util.GetEnvInt("BATCH_MAX_SIZE", 100)
+	if maxBatchSize < 1 {
+		return nil, fmt.Errorf("BATCH_MAX_SIZE inválido: %d (mínimo 1)", maxBatchSize)
+	}
+
+	h := &APIHandler{
 		FinderService: service.NewFinderService(),
+		AdminToken:    os.Getenv("ADMIN_TOKEN"),
+		StrictStatus:  util.GetEnvBool("STRICT_HTTP_STATUS", false),
+		MaxBatchSize:  maxBatchSize,
 	}
+	registerMetrics(h.FinderService)
+	return h, nil
 }
 
 // writeJSON é um utilitário para escrever a resposta JSON.
@@ -26,11 +56,26 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.WriteHeader(status)
 	// Trata o erro de encoding, embora seja raro em structs simples
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		fmt.Printf("Erro ao escrever resposta JSON: %v\n", err)
+		slog.Error("erro ao escrever resposta JSON", "error", err)
 	}
 }
 
-// HealthCheckHandler verifica a saúde do serviço.
+// WithRequestID associa a cada requisição um ID, reaproveitando o cabeçalho
+// X-Request-ID recebido ou gerando um novo, e o devolve na resposta. Os logs
+// feitos com o contexto da requisição incluem o campo request_id.
+func WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" || len(id) > 128 {
+			id = util.NewRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(util.WithRequestID(r.Context(), id)))
+	})
+}
+
+// HealthCheckHandler verifica se o processo está vivo (liveness).
+// Não verifica dependências; para isso use ReadinessHandler.
 // GET /api/healthz
 func (h *APIHandler) HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	// O roteador ServeMux do Go atende a todos os métodos,
@@ -41,18 +86,44 @@ func (h *APIHandler) HealthCheckHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	writeJSON(w, http.StatusOK, util.HealthzResponse{
-		Status: "ok",
+		Status:        "ok",
+		ConfigVersion: h.FinderService.ConfigVersion(),
+	})
+}
+
+// ReadinessHandler verifica se o serviço está pronto para receber tráfego,
+// detalhando o estado de cada componente. Responde 503 se algum falhar.
+// GET /api/readyz
+func (h *APIHandler) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	ready, components := h.FinderService.Readiness(r.Context())
+
+	if !ready {
+		writeJSON(w, http.StatusServiceUnavailable, util.ReadyzResponse{
+			Status:        "not_ready",
+			ConfigVersion: h.FinderService.ConfigVersion(),
+			Components:    components,
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, util.ReadyzResponse{
+		Status:        "ok",
+		ConfigVersion: h.FinderService.ConfigVersion(),
+		Components:    components,
 	})
 }
 
 // FindServiceHandler processa a solicitação e chama a IA para roteamento.
 // POST /api/find-service
 func (h *APIHandler) FindServiceHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Config-Version", h.FinderService.ConfigVersion())
+
 	// A rota só é acessada via POST, mas é bom garantir.
 	if r.Method != http.MethodPost {
-		writeJSON(w, http.StatusOK, util.FindServiceResponse{
-			Success: false,
-			Error:   "Método não permitido. Use POST.",
+		h.writeFindService(w, util.FindServiceResponse{
+			Success:   false,
+			Error:     "Método não permitido. Use POST.",
+			ErrorCode: util.ErrMethodNotAllowed,
 		})
 		return
 	}
@@ -61,16 +132,204 @@ func (h *APIHandler) FindServiceHandler(w http.ResponseWriter, r *http.Request)
 
 	// 1. Binding do JSON de entrada (usando a biblioteca padrão)
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Intent == "" {
-		writeJSON(w, http.StatusOK, util.FindServiceResponse{
-			Success: false,
-			Error:   "Corpo da requisição inválido. Esperado {\"intent\": \"string\"}",
+		h.writeFindService(w, util.FindServiceResponse{
+			Success:   false,
+			Error:     "Corpo da requisição inválido. Esperado {\"intent\": \"string\"}",
+			ErrorCode: util.ErrInvalidBody,
 		})
 		return
 	}
 
-	// 2. Chama o serviço de IA para encontrar o serviço mais adequado
-	response := h.FinderService.FindService(req.Intent)
+	// O modo detalhado (top-K) pode ser ativado pelo corpo ou por query param
+	if topK, err := strconv.Atoi(r.URL.Query().Get("top_k")); err == nil {
+		req.TopK = topK
+	}
+	if ensemble, err := strconv.ParseBool(r.URL.Query().Get("ensemble")); err == nil {
+		req.Ensemble = &ensemble
+	}
+	if clarify, err := strconv.ParseBool(r.URL.Query().Get("clarify")); err == nil {
+		req.Clarify = clarify
+	}
+
+	// 2. Chama o serviço de IA para encontrar o serviço mais adequado. Os
+	// campos registrados pelas camadas (cache, etapas, IA) vão para reqLog.
+	start := time.Now()
+	reqLog := &util.RequestLog{}
+	ctx := util.WithRequestLog(r.Context(), reqLog)
+	ctx = tracing.WithRemoteParent(ctx, r.Header.Get("traceparent"))
+	ctx, span := tracing.Start(ctx, "POST /api/find-service", tracing.KindServer)
+	response := h.FinderService.FindService(ctx, req.Intent, service.FindOptions{
+		Ensemble:           req.Ensemble,
+		SessionID:          req.SessionID,
+		History:            req.History,
+		Clarify:            req.Clarify,
+		ClarificationToken: req.ClarificationToken,
+	})
+	elapsed := time.Since(start)
+	traceFindService(ctx, span, response)
+	logFindService(ctx, reqLog, response, elapsed)
+	observeFindService(response, elapsed)
 
 	// 3. Resposta
+	if response.Stage != "" {
+		w.Header().Set("X-Classifier-Stage", response.Stage)
+	}
+	h.writeFindService(w, service.ShapeResponse(response, req.TopK))
+}
+
+// writeFindService escreve a resposta de /api/find-service com o status do
+// código de erro, se StrictStatus estiver ativo, ou 200.
+func (h *APIHandler) writeFindService(w http.ResponseWriter, response util.FindServiceResponse) {
+	writeJSON(w, h.responseStatus(w, response.Success, response.ErrorCode), response)
+}
+
+// responseStatus retorna 200 para sucessos ou sem StrictStatus e, nos demais
+// casos, o status do código de erro.
+func (h *APIHandler) responseStatus(w http.ResponseWriter, success bool, code util.ErrorCode) int {
+	if success || !h.StrictStatus {
+		return http.StatusOK
+	}
+	status := statusFor(code)
+	if status == http.StatusMethodNotAllowed {
+		w.Header().Set("Allow", http.MethodPost)
+	}
+	return status
+}
+
+// statusFor mapeia o código de erro para o status HTTP (veja
+// docs/error_codes.md). NO_MATCH e NEEDS_CLARIFICATION não são falhas da
+// requisição: a intenção foi processada e não corresponde a nenhum serviço
+// ou precisa ser confirmada com o cliente.
+func statusFor(code util.ErrorCode) int {
+	switch code {
+	case util.ErrInvalidBody:
+		return http.StatusBadRequest
+	case util.ErrMethodNotAllowed:
+		return http.StatusMethodNotAllowed
+	case util.ErrBatchTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case util.ErrNoMatch, util.ErrNeedsClarification:
+		return http.StatusOK
+	case util.ErrUpstreamError, util.ErrInvalidModelOutput:
+		return http.StatusBadGateway
+	case util.ErrUpstreamTimeout:
+		return http.StatusGatewayTimeout
+	case util.ErrShuttingDown:
+		return http.StatusServiceUnavailable
+	case util.ErrCanceled:
+		return statusClientClosedRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// statusClientClosedRequest é o status não padrão (nginx) para requisições
+// abandonadas pelo cliente; normalmente ninguém chega a lê-lo.
+const statusClientClosedRequest = 499
+
+// logFindService registra uma linha por classificação com os campos acumulados.
+func logFindService(ctx context.Context, reqLog *util.RequestLog, response util.FindServiceResponse, elapsed time.Duration) {
+	attrs := append(reqLog.Attrs(),
+		"success", response.Success,
+		"stage", response.Stage,
+		"service_id", response.Data.ServiceID,
+		"duration_ms", float64(elapsed.Microseconds())/1000,
+	)
+	if response.Error != "" {
+		attrs = append(attrs, "error", response.Error, "error_code", response.ErrorCode)
+	}
+	if traceID := tracing.FromContext(ctx).TraceID(); traceID != "" {
+		attrs = append(attrs, "trace_id", traceID)
+	}
+	slog.InfoContext(ctx, "find-service", attrs...)
+}
+
+// traceFindService completa e encerra o span da requisição.
+func traceFindService(ctx context.Context, span *tracing.Span, response util.FindServiceResponse) {
+	span.Set("request_id", util.RequestID(ctx))
+	span.Set("ivr.success", response.Success)
+	span.Set("ivr.service_id", response.Data.ServiceID)
+	span.Set("ivr.stage", response.Stage)
+	if !response.Success {
+		span.Set("ivr.error_code", string(response.ErrorCode))
+		span.SetError(errors.New(response.Error))
+	}
+	span.End()
+}
+
+// StatsHandler retorna quantas respostas cada etapa da cascata produziu.
+// GET /api/stats
+func (h *APIHandler) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats := util.StatsResponse{
+		Stages:    h.FinderService.StageStats(),
+		Cache:     h.FinderService.CacheStats(),
+		Coalesced: h.FinderService.CoalescedCount(),
+	}
+	if hedges, ok := h.FinderService.HedgeStats(); ok {
+		stats.Hedges = &util.HedgeStats{Fired: hedges.Fired, Won: hedges.Won}
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// SessionHandler encerra a sessão de uma ligação, descartando os turnos
+// guardados (sem isso, a sessão expira após SESSION_TTL sem novos turnos).
+// DELETE /api/sessions/{id}
+func (h *APIHandler) SessionHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/sessions/")
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", http.MethodDelete)
+		writeJSON(w, http.StatusMethodNotAllowed, util.EndSessionResponse{Error: "Método não permitido. Use DELETE."})
+		return
+	}
+
+	turns, ok := h.FinderService.EndSession(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, util.EndSessionResponse{Error: "sessão não encontrada"})
+		return
+	}
+	writeJSON(w, http.StatusOK, util.EndSessionResponse{Success: true, Turns: turns})
+}
+
+// ReloadHandler recarrega catálogo, dataset e template de prompt sem reiniciar
+// o serviço (o mesmo que enviar SIGHUP). Exige "Authorization: Bearer
+// <ADMIN_TOKEN>" e fica desativado se ADMIN_TOKEN não estiver definido.
+// POST /api/admin/reload
+func (h *APIHandler) ReloadHandler(w http.ResponseWriter, r *http.Request) {
+	if h.AdminToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if !h.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, util.ReloadResponse{Error: "token administrativo inválido"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, util.ReloadResponse{Error: "Método não permitido. Use POST."})
+		return
+	}
+
+	result, err := h.FinderService.Reload()
+	response := util.ReloadResponse{
+		Success:         err == nil,
+		Version:         result.Version,
+		PreviousVersion: result.PreviousVersion,
+		Changed:         result.Changed,
+		Invalidated:     result.Invalidated,
+	}
+	if err != nil {
+		response.Error = err.Error()
+		writeJSON(w, http.StatusUnprocessableEntity, response)
+		return
+	}
 	writeJSON(w, http.StatusOK, response)
 }
+
+// authorized compara o token Bearer da requisição com AdminToken em tempo constante.
+func (h *APIHandler) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(h.AdminToken)) == 1
+}
@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"herois-da-pilha/cache"
+	"herois-da-pilha/normalize"
+	"herois-da-pilha/service"
+	"herois-da-pilha/util"
+)
+
+// upstreamClassifier simula a etapa de IA, registrando a chamada ao modelo
+// como o LLMClassifier.
+type upstreamClassifier struct{}
+
+func (upstreamClassifier) Classify(ctx context.Context, _ string) (service.Prediction, error) {
+	util.RequestLogFrom(ctx).AddUpstream("modelo-teste", 42*time.Millisecond, 120, 4)
+	return service.Prediction{ServiceID: 13, Score: 0.95, Candidates: []service.Candidate{{ServiceID: 13, Confidence: 0.95}}}, nil
+}
+
+func newTestHandler(t *testing.T, classifier service.Classifier) *APIHandler {
+	t.Helper()
+	res, err := service.LoadResources("", "", "", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	finder := service.NewFinderServiceWith(res, classifier, cache.NewLRU(cache.Options[util.FindServiceResponse]{}))
+	t.Cleanup(func() { finder.Close(context.Background()) })
+	return &APIHandler{FinderService: finder}
+}
+
+// captureLogs direciona o slog padrão para um buffer em JSON, com o mesmo
+// handler de contexto de util.SetupLogger, até o fim do teste.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(util.NewContextHandler(slog.NewJSONHandler(&buf, nil))))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// findServiceLogs retorna as linhas "find-service" registradas.
+func findServiceLogs(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var line map[string]any
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("log inválido %q: %v", raw, err)
+		}
+		if line["msg"] == "find-service" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestFindServiceRequestLog(t *testing.T) {
+	h := newTestHandler(t, &service.CascadeClassifier{Stages: []service.Stage{{Name: service.StageLLM, Classifier: upstreamClassifier{}}}})
+	logs := captureLogs(t)
+	srv := WithRequestID(http.HandlerFunc(h.FindServiceHandler))
+
+	const intent = "quero pagar a fatura"
+	post := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/find-service", strings.NewReader(`{"intent": "`+intent+`"}`))
+		if requestID != "" {
+			req.Header.Set("X-Request-ID", requestID)
+		}
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// X-Request-ID recebido é mantido e devolvido
+	if got := post("ligacao-123").Header().Get("X-Request-ID"); got != "ligacao-123" {
+		t.Errorf("X-Request-ID = %q, want o recebido", got)
+	}
+	// Sem o cabeçalho, um ID é gerado (a intenção repetida vem do cache)
+	generated := post("").Header().Get("X-Request-ID")
+	if generated == "" || generated == "ligacao-123" {
+		t.Errorf("X-Request-ID gerado = %q", generated)
+	}
+
+	lines := findServiceLogs(t, logs)
+	if len(lines) != 2 {
+		t.Fatalf("%d linhas find-service, want 2:\n%s", len(lines), logs)
+	}
+	miss, hit := lines[0], lines[1]
+
+	wantHash := util.IntentHash(normalize.Normalize(intent))
+	for key, want := range map[string]any{
+		"request_id":  "ligacao-123",
+		"intent_hash": wantHash,
+		"cache":       "miss",
+		"stage":       service.StageLLM,
+		"service_id":  float64(13),
+		"success":     true,
+	} {
+		if miss[key] != want {
+			t.Errorf("miss: %s = %v, want %v", key, miss[key], want)
+		}
+	}
+	if _, ok := miss["duration_ms"].(float64); !ok {
+		t.Errorf("miss: duration_ms ausente: %v", miss)
+	}
+	if stages, _ := miss["stages"].([]any); len(stages) != 1 || stages[0].(map[string]any)["stage"] != service.StageLLM {
+		t.Errorf("miss: stages = %v", miss["stages"])
+	}
+	upstream, _ := miss["upstream"].([]any)
+	if len(upstream) != 1 {
+		t.Fatalf("miss: upstream = %v, want 1 chamada", miss["upstream"])
+	}
+	call := upstream[0].(map[string]any)
+	for key, want := range map[string]any{
+		"model":             "modelo-teste",
+		"latency_ms":        float64(42),
+		"prompt_tokens":     float64(120),
+		"completion_tokens": float64(4),
+	} {
+		if call[key] != want {
+			t.Errorf("upstream: %s = %v, want %v", key, call[key], want)
+		}
+	}
+
+	for key, want := range map[string]any{
+		"request_id":  generated,
+		"intent_hash": wantHash,
+		"cache":       "hit",
+		"stage":       service.StageCache,
+		"service_id":  float64(13),
+	} {
+		if hit[key] != want {
+			t.Errorf("hit: %s = %v, want %v", key, hit[key], want)
+		}
+	}
+	if _, ok := hit["upstream"]; ok {
+		t.Errorf("hit: upstream registrado sem chamada à IA: %v", hit["upstream"])
+	}
+}
+
+// errClassifier falha sempre com err.
+type errClassifier struct{ err error }
+
+func (c errClassifier) Classify(context.Context, string) (service.Prediction, error) {
+	return service.Prediction{}, c.err
+}
+
+// statusTable lê a tabela de status de docs/error_codes.md.
+func statusTable(t *testing.T) map[util.ErrorCode]int {
+	t.Helper()
+	raw, err := os.ReadFile("../docs/error_codes.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := regexp.MustCompile("(?m)^\\| `([A-Z_]+)` +\\| (\\d{3}) ")
+	table := make(map[util.ErrorCode]int)
+	for _, m := range row.FindAllStringSubmatch(string(raw), -1) {
+		status, _ := strconv.Atoi(m[2])
+		table[util.ErrorCode(m[1])] = status
+	}
+	return table
+}
+
+func TestStatusForDocumentedCodes(t *testing.T) {
+	table := statusTable(t)
+	codes := []util.ErrorCode{
+		util.ErrInvalidBody, util.ErrMethodNotAllowed, util.ErrBatchTooLarge,
+		util.ErrNoMatch, util.ErrNeedsClarification, util.ErrUpstreamError,
+		util.ErrInvalidModelOutput, util.ErrUpstreamTimeout, util.ErrShuttingDown,
+		util.ErrCanceled, util.ErrInternal,
+	}
+	if len(table) != len(codes) {
+		t.Errorf("a documentação lista %d códigos, want %d: %v", len(table), len(codes), table)
+	}
+	for _, code := range codes {
+		want, ok := table[code]
+		if !ok {
+			t.Errorf("%s ausente de docs/error_codes.md", code)
+			continue
+		}
+		if got := statusFor(code); got != want {
+			t.Errorf("statusFor(%s) = %d, documentado %d", code, got, want)
+		}
+	}
+}
+
+func TestFindServiceStrictStatus(t *testing.T) {
+	table := statusTable(t)
+	tests := []struct {
+		name       string
+		classifier service.Classifier
+		method     string
+		body       string
+		wantCode   util.ErrorCode
+	}{
+		{"sucesso", stubClassifier{}, http.MethodPost, `{"intent": "limite"}`, ""},
+		{"corpo inválido", stubClassifier{}, http.MethodPost, `{"intent": ""}`, util.ErrInvalidBody},
+		{"método", stubClassifier{}, http.MethodGet, ``, util.ErrMethodNotAllowed},
+		{"sem correspondência", errClassifier{service.ErrNoMatch}, http.MethodPost, `{"intent": "xyz"}`, util.ErrNoMatch},
+		{"falha da IA", errClassifier{errors.New("429")}, http.MethodPost, `{"intent": "xyz"}`, util.ErrUpstreamError},
+		{"resposta inválida", errClassifier{service.ErrInvalidModelOutput}, http.MethodPost, `{"intent": "xyz"}`, util.ErrInvalidModelOutput},
+		{"timeout", errClassifier{context.DeadlineExceeded}, http.MethodPost, `{"intent": "xyz"}`, util.ErrUpstreamTimeout},
+	}
+
+	for _, strict := range []bool{false, true} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/estrito=%v", tt.name, strict), func(t *testing.T) {
+				h := newTestHandler(t, tt.classifier)
+				h.StrictStatus = strict
+
+				rec := httptest.NewRecorder()
+				h.FindServiceHandler(rec, httptest.NewRequest(tt.method, "/api/find-service", strings.NewReader(tt.body)))
+
+				var resp util.FindServiceResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.ErrorCode != tt.wantCode {
+					t.Fatalf("error_code = %q, want %q", resp.ErrorCode, tt.wantCode)
+				}
+
+				want := http.StatusOK
+				if strict && tt.wantCode != "" {
+					want = table[tt.wantCode]
+				}
+				if rec.Code != want {
+					t.Errorf("status = %d, want %d", rec.Code, want)
+				}
+				if allow := rec.Header().Get("Allow"); strict && tt.wantCode == util.ErrMethodNotAllowed && allow != http.MethodPost {
+					t.Errorf("Allow = %q, want POST", allow)
+				}
+			})
+		}
+	}
+}
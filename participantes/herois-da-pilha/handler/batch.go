@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"herois-da-pilha/service"
+	"herois-da-pilha/tracing"
+	"herois-da-pilha/util"
+)
+
+// maxBatchItemBytes é o tamanho médio aceito por item do lote; o corpo da
+// requisição é limitado a MaxBatchSize * maxBatchItemBytes.
+const maxBatchItemBytes = 2048
+
+// BatchFindServiceHandler classifica um lote de intenções numa única
+// requisição. Cada item traz um ID escolhido pelo cliente, que volta no
+// resultado correspondente; falhas de um item não afetam os demais.
+// POST /api/find-service/batch
+func (h *APIHandler) BatchFindServiceHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Config-Version", h.FinderService.ConfigVersion())
+
+	if r.Method != http.MethodPost {
+		h.writeBatch(w, util.FindServiceBatchResponse{
+			Error:     "Método não permitido. Use POST.",
+			ErrorCode: util.ErrMethodNotAllowed,
+		})
+		return
+	}
+
+	var req util.FindServiceBatchRequest
+	body := http.MaxBytesReader(w, r.Body, int64(h.MaxBatchSize)*maxBatchItemBytes)
+	err := json.NewDecoder(body).Decode(&req)
+	if tooLarge := (*http.MaxBytesError)(nil); errors.As(err, &tooLarge) {
+		h.writeBatch(w, util.FindServiceBatchResponse{
+			Error:     fmt.Sprintf("corpo do lote excede %d bytes", tooLarge.Limit),
+			ErrorCode: util.ErrBatchTooLarge,
+		})
+		return
+	}
+	if err != nil {
+		h.writeBatch(w, util.FindServiceBatchResponse{
+			Error:     "Corpo da requisição inválido. Esperado {\"items\": [{\"id\": \"string\", \"intent\": \"string\"}]}",
+			ErrorCode: util.ErrInvalidBody,
+		})
+		return
+	}
+	if err := service.ValidateBatch(req.Items, h.MaxBatchSize); err != nil {
+		code := util.ErrInvalidBody
+		if errors.Is(err, service.ErrBatchTooLarge) {
+			code = util.ErrBatchTooLarge
+		}
+		h.writeBatch(w, util.FindServiceBatchResponse{Error: err.Error(), ErrorCode: code})
+		return
+	}
+
+	if topK, err := strconv.Atoi(r.URL.Query().Get("top_k")); err == nil {
+		req.TopK = topK
+	}
+	if ensemble, err := strconv.ParseBool(r.URL.Query().Get("ensemble")); err == nil {
+		req.Ensemble = &ensemble
+	}
+
+	start := time.Now()
+	ctx := tracing.WithRemoteParent(r.Context(), r.Header.Get("traceparent"))
+	ctx, span := tracing.Start(ctx, "POST /api/find-service/batch", tracing.KindServer)
+	results := h.FinderService.FindServiceBatch(ctx, req.Items, service.FindOptions{
+		Ensemble: req.Ensemble,
+	})
+	elapsed := time.Since(start)
+
+	failures := 0
+	for i := range results {
+		observeItem(batchItemsTotal, results[i].FindServiceResponse)
+		if !results[i].Success {
+			failures++
+		}
+		results[i].FindServiceResponse = service.ShapeResponse(results[i].FindServiceResponse, req.TopK)
+	}
+
+	span.Set("request_id", util.RequestID(ctx))
+	span.Set("ivr.batch_size", len(req.Items))
+	span.Set("ivr.batch_failures", failures)
+	span.End()
+
+	attrs := []any{"items", len(req.Items), "failures", failures, "duration_ms", float64(elapsed.Microseconds()) / 1000}
+	if traceID := span.TraceID(); traceID != "" {
+		attrs = append(attrs, "trace_id", traceID)
+	}
+	slog.InfoContext(ctx, "find-service-batch", attrs...)
+
+	h.writeBatch(w, util.FindServiceBatchResponse{Success: true, Results: results})
+}
+
+// writeBatch escreve a resposta do lote. Lotes aceitos respondem 200 mesmo
+// com itens que falharam (veja responseStatus).
+func (h *APIHandler) writeBatch(w http.ResponseWriter, response util.FindServiceBatchResponse) {
+	writeJSON(w, h.responseStatus(w, response.Success, response.ErrorCode), response)
+}
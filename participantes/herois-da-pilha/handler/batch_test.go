@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"herois-da-pilha/service"
+	"herois-da-pilha/util"
+)
+
+// delayedClassifier responde o serviço indicado na intenção ("servico N"),
+// terminando os IDs menores por último, para embaralhar a ordem de conclusão.
+type delayedClassifier struct{}
+
+func (delayedClassifier) Classify(ctx context.Context, intent string) (service.Prediction, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(intent, "servico "))
+	if err != nil {
+		return service.Prediction{}, service.ErrNoMatch
+	}
+	select {
+	case <-time.After(time.Duration(20-id) * time.Millisecond):
+	case <-ctx.Done():
+		return service.Prediction{}, ctx.Err()
+	}
+	return service.Prediction{ServiceID: id, Score: 1}, nil
+}
+
+func postBatch(t *testing.T, h *APIHandler, body string) (int, util.FindServiceBatchResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.BatchFindServiceHandler(rec, httptest.NewRequest(http.MethodPost, "/api/find-service/batch", strings.NewReader(body)))
+	var resp util.FindServiceBatchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, resp
+}
+
+func TestBatchRejectsInvalidBatches(t *testing.T) {
+	h := newTestHandler(t, delayedClassifier{})
+	h.MaxBatchSize = 2
+	h.StrictStatus = true
+
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   util.ErrorCode
+		wantStatus int
+	}{
+		{"JSON inválido", `{"items": [`, util.ErrInvalidBody, http.StatusBadRequest},
+		{"vazio", `{"items": []}`, util.ErrInvalidBody, http.StatusBadRequest},
+		{"sem id", `{"items": [{"id": "a", "intent": "servico 1"}, {"intent": "servico 2"}]}`, util.ErrInvalidBody, http.StatusBadRequest},
+		{"id repetido", `{"items": [{"id": "a", "intent": "servico 1"}, {"id": "a", "intent": "servico 2"}]}`, util.ErrInvalidBody, http.StatusBadRequest},
+		{"itens demais", `{"items": [{"id": "a", "intent": "servico 1"}, {"id": "b", "intent": "servico 2"}, {"id": "c", "intent": "servico 3"}]}`, util.ErrBatchTooLarge, http.StatusRequestEntityTooLarge},
+		// O corpo é limitado a MaxBatchSize * maxBatchItemBytes
+		{"corpo grande demais", `{"items": [{"id": "a", "intent": "` + strings.Repeat("x", 2*maxBatchItemBytes) + `"}]}`, util.ErrBatchTooLarge, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := postBatch(t, h, tt.body)
+			if resp.Success || resp.ErrorCode != tt.wantCode {
+				t.Errorf("resposta = %+v, want error_code %s", resp, tt.wantCode)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestBatchKeepsItemOrder(t *testing.T) {
+	h := newTestHandler(t, delayedClassifier{})
+	h.MaxBatchSize = 10
+
+	var items []util.BatchItem
+	for _, id := range []int{16, 1, 7, 3, 13} {
+		items = append(items, util.BatchItem{ID: "item-" + strconv.Itoa(id), Intent: "servico " + strconv.Itoa(id)})
+	}
+	items = append(items, util.BatchItem{ID: "vazio"})
+	body, _ := json.Marshal(util.FindServiceBatchRequest{Items: items})
+
+	status, resp := postBatch(t, h, string(body))
+	if status != http.StatusOK || !resp.Success {
+		t.Fatalf("lote rejeitado: %d %+v", status, resp)
+	}
+	if len(resp.Results) != len(items) {
+		t.Fatalf("%d resultados, want %d", len(resp.Results), len(items))
+	}
+	for i, item := range items {
+		result := resp.Results[i]
+		if result.ID != item.ID {
+			t.Errorf("resultado %d = %q, want %q", i, result.ID, item.ID)
+		}
+		if item.Intent == "" {
+			if result.Success || result.ErrorCode != util.ErrInvalidBody {
+				t.Errorf("item sem intenção: %+v, want INVALID_BODY", result)
+			}
+			continue
+		}
+		if want, _ := strconv.Atoi(strings.TrimPrefix(item.Intent, "servico ")); !result.Success || result.Data.ServiceID != want {
+			t.Errorf("item %s: %+v, want serviço %d", item.ID, result.FindServiceResponse, want)
+		}
+	}
+}
+
+func TestNewAPIHandlerRejectsBatchMaxSize(t *testing.T) {
+	for _, size := range []string{"0", "-5"} {
+		t.Setenv("BATCH_MAX_SIZE", size)
+		if _, err := NewAPIHandler(); err == nil {
+			t.Errorf("BATCH_MAX_SIZE=%s aceito", size)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"herois-da-pilha/metrics"
+	"herois-da-pilha/service"
+	"herois-da-pilha/util"
+)
+
+var (
+	requestsTotal = metrics.NewCounterVec("ivr_requests_total",
+		"Requisições a /api/find-service, por resultado (success ou o código de erro em minúsculas, ex: no_match, upstream_timeout).", "outcome")
+	requestDuration = metrics.NewHistogramVec("ivr_request_duration_seconds",
+		"Latência de /api/find-service, por resultado.", metrics.DefBuckets, "outcome")
+	batchItemsTotal = metrics.NewCounterVec("ivr_batch_items_total",
+		"Itens classificados por /api/find-service/batch, por resultado (como em ivr_requests_total).", "outcome")
+	streamItemsTotal = metrics.NewCounterVec("ivr_stream_items_total",
+		"Linhas classificadas por /api/find-service/stream, por resultado (como em ivr_requests_total).", "outcome")
+	classificationsTotal = metrics.NewCounterVec("ivr_classifications_total",
+		"Classificações bem-sucedidas, por serviço e etapa que respondeu (incluindo cache).", "service_id", "stage")
+)
+
+// registerMetrics registra as métricas lidas do FinderService a cada coleta.
+func registerMetrics(s *service.FinderService) {
+	metrics.NewGaugeFunc("ivr_job_queue_depth", "Jobs aguardando um worker livre.", func() float64 {
+		return float64(s.QueueDepth())
+	})
+	metrics.NewGaugeFunc("ivr_busy_workers", "Workers classificando no momento.", func() float64 {
+		return float64(s.BusyWorkers())
+	})
+	metrics.NewCounterFunc("ivr_cache_hits_total", "Acertos do cache de respostas.", func() float64 {
+		return float64(s.CacheStats().Hits)
+	})
+	metrics.NewCounterFunc("ivr_cache_misses_total", "Faltas do cache de respostas.", func() float64 {
+		return float64(s.CacheStats().Misses)
+	})
+	metrics.NewGaugeFunc("ivr_cache_hit_ratio", "Fração das consultas ao cache que foram acertos.", func() float64 {
+		stats := s.CacheStats()
+		if total := stats.Hits + stats.Misses; total > 0 {
+			return float64(stats.Hits) / float64(total)
+		}
+		return 0
+	})
+	metrics.NewGaugeFunc("ivr_cache_entries", "Entradas no cache de respostas.", func() float64 {
+		return float64(s.CacheStats().Entries)
+	})
+	metrics.NewGaugeFunc("ivr_sessions", "Sessões de ligação guardadas para classificação com contexto.", func() float64 {
+		return float64(s.SessionCount())
+	})
+	metrics.NewCounterFunc("ivr_coalesced_requests_total", "Requisições que aguardaram uma classificação idêntica em andamento.", func() float64 {
+		return float64(s.CoalescedCount())
+	})
+}
+
+// observeFindService registra as métricas de uma chamada a /api/find-service.
+func observeFindService(response util.FindServiceResponse, elapsed time.Duration) {
+	outcome := requestOutcome(response)
+	requestsTotal.With(outcome).Inc()
+	requestDuration.With(outcome).Observe(elapsed.Seconds())
+	if response.Success {
+		classificationsTotal.With(strconv.Itoa(response.Data.ServiceID), response.Stage).Inc()
+	}
+}
+
+// observeItem registra o resultado de um item de lote ou de fluxo NDJSON.
+func observeItem(items *metrics.CounterVec, response util.FindServiceResponse) {
+	items.With(requestOutcome(response)).Inc()
+	if response.Success {
+		classificationsTotal.With(strconv.Itoa(response.Data.ServiceID), response.Stage).Inc()
+	}
+}
+
+// requestOutcome classifica a resposta para as métricas.
+func requestOutcome(response util.FindServiceResponse) string {
+	switch {
+	case response.Success:
+		return "success"
+	case response.ErrorCode == "":
+		return "error"
+	default:
+		return strings.ToLower(string(response.ErrorCode))
+	}
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"herois-da-pilha/service"
+	"herois-da-pilha/tracing"
+	"herois-da-pilha/util"
+)
+
+// streamIdleTimeout é o prazo para ler a próxima linha ou escrever um
+// resultado; o fluxo como um todo não tem limite de duração.
+const streamIdleTimeout = 30 * time.Second
+
+// maxStreamLine é o tamanho máximo de uma linha do fluxo; linhas maiores
+// geram um resultado com INVALID_BODY e a leitura segue na próxima.
+const maxStreamLine = 64 << 10
+
+// StreamFindServiceHandler classifica intenções enviadas em NDJSON, uma por
+// linha ({"id": "...", "intent": "..."}; sem id, vale o número da linha), e
+// devolve um resultado NDJSON por linha (como os itens de
+// /api/find-service/batch) assim que cada classificação termina, fora da
+// ordem de entrada. Linhas inválidas geram um resultado com INVALID_BODY.
+//
+// A leitura do corpo acompanha a capacidade do pool de workers (veja
+// service.FinderService.FindServiceStream): com o pool ocupado ou com o
+// cliente lendo devagar, o corpo deixa de ser lido e o TCP segura o envio.
+// POST /api/find-service/stream
+func (h *APIHandler) StreamFindServiceHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Config-Version", h.FinderService.ConfigVersion())
+
+	if r.Method != http.MethodPost {
+		h.writeFindService(w, util.FindServiceResponse{
+			Error:     "Método não permitido. Use POST.",
+			ErrorCode: util.ErrMethodNotAllowed,
+		})
+		return
+	}
+
+	// Respostas começam a sair antes do fim do corpo, e o fluxo pode durar
+	// mais que os timeouts do servidor: os prazos passam a valer por linha.
+	rc := http.NewResponseController(w)
+	if err := rc.EnableFullDuplex(); err != nil {
+		slog.WarnContext(r.Context(), "full duplex indisponível", "error", err)
+	}
+
+	topK, _ := strconv.Atoi(r.URL.Query().Get("top_k"))
+	opts := service.FindOptions{}
+	if ensemble, err := strconv.ParseBool(r.URL.Query().Get("ensemble")); err == nil {
+		opts.Ensemble = &ensemble
+	}
+
+	ctx, cancel := context.WithCancel(tracing.WithRemoteParent(r.Context(), r.Header.Get("traceparent")))
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "POST /api/find-service/stream", tracing.KindServer)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	// out serializa as linhas de resultado; uma escrita que falha (cliente
+	// desconectado) cancela o restante do fluxo.
+	var (
+		mu       sync.Mutex
+		enc      = json.NewEncoder(w)
+		items    int
+		failures int
+	)
+	out := func(id string, response util.FindServiceResponse) {
+		mu.Lock()
+		defer mu.Unlock()
+		items++
+		observeItem(streamItemsTotal, response)
+		if !response.Success {
+			failures++
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		rc.SetWriteDeadline(time.Now().Add(streamIdleTimeout))
+		err := enc.Encode(util.BatchResult{ID: id, FindServiceResponse: service.ShapeResponse(response, topK)})
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			cancel()
+		}
+	}
+
+	start := time.Now()
+	body := bufio.NewReader(r.Body)
+	line := 0
+	var readErr error
+	next := func() (service.StreamItem, bool) {
+		for readErr == nil {
+			rc.SetReadDeadline(time.Now().Add(streamIdleTimeout))
+			var (
+				raw     []byte
+				tooLong bool
+			)
+			raw, tooLong, readErr = readLine(body)
+			if readErr != nil && !errors.Is(readErr, io.EOF) {
+				out(strconv.Itoa(line+1), util.FindServiceResponse{
+					Error:     "erro ao ler a entrada: " + readErr.Error(),
+					ErrorCode: util.ErrInvalidBody,
+				})
+				return service.StreamItem{}, false
+			}
+			line++
+			if tooLong {
+				out(strconv.Itoa(line), util.FindServiceResponse{
+					Error:     "linha acima do limite de 64KB",
+					ErrorCode: util.ErrInvalidBody,
+				})
+				continue
+			}
+			raw = bytes.TrimSpace(raw)
+			if len(raw) == 0 {
+				continue
+			}
+
+			var item util.BatchItem
+			if err := json.Unmarshal(raw, &item); err != nil || item.Intent == "" {
+				if item.ID == "" {
+					item.ID = strconv.Itoa(line)
+				}
+				out(item.ID, util.FindServiceResponse{
+					Error:     "linha inválida. Esperado {\"id\": \"string\", \"intent\": \"string\"}",
+					ErrorCode: util.ErrInvalidBody,
+				})
+				continue
+			}
+			if item.ID == "" {
+				item.ID = strconv.Itoa(line)
+			}
+			return service.StreamItem{ID: item.ID, Intent: item.Intent}, true
+		}
+		return service.StreamItem{}, false
+	}
+
+	h.FinderService.FindServiceStream(ctx, next, func(item service.StreamItem, response util.FindServiceResponse) {
+		out(item.ID, response)
+	}, opts)
+
+	span.Set("request_id", util.RequestID(ctx))
+	span.Set("ivr.stream_items", items)
+	span.Set("ivr.stream_failures", failures)
+	span.SetError(ctx.Err())
+	span.End()
+
+	attrs := []any{"items", items, "failures", failures, "duration_ms", float64(time.Since(start).Microseconds()) / 1000}
+	if err := ctx.Err(); err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	if traceID := span.TraceID(); traceID != "" {
+		attrs = append(attrs, "trace_id", traceID)
+	}
+	slog.InfoContext(ctx, "find-service-stream", attrs...)
+}
+
+// readLine lê a próxima linha de r, sem o separador. Uma linha acima de
+// maxStreamLine é consumida até o fim sem ser guardada e retorna tooLong. Na
+// última linha, err é io.EOF junto com o conteúdo lido.
+func readLine(r *bufio.Reader) (line []byte, tooLong bool, err error) {
+	for {
+		var chunk []byte
+		chunk, err = r.ReadSlice('\n')
+		if !tooLong {
+			line = append(line, chunk...)
+			if err == nil {
+				line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+			}
+			if len(line) > maxStreamLine {
+				line, tooLong = nil, true
+			}
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return line, tooLong, err
+		}
+	}
+}
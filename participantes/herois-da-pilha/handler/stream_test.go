@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"herois-da-pilha/cache"
+	"herois-da-pilha/service"
+	"herois-da-pilha/util"
+)
+
+// stubClassifier responde sempre com o serviço 1.
+type stubClassifier struct{}
+
+func (stubClassifier) Classify(context.Context, string) (service.Prediction, error) {
+	return service.Prediction{ServiceID: 1, Score: 1, Candidates: []service.Candidate{{ServiceID: 1, Confidence: 1}}, Stage: service.StageExact}, nil
+}
+
+// Uma linha acima do limite gera INVALID_BODY só para ela; as seguintes
+// continuam sendo classificadas.
+func TestStreamSkipsOverlongLine(t *testing.T) {
+	res, err := service.LoadResources("", "", "", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	finder := service.NewFinderServiceWith(res, stubClassifier{}, cache.NewLRU(cache.Options[util.FindServiceResponse]{}))
+	defer finder.Close(context.Background())
+	h := &APIHandler{FinderService: finder}
+
+	body := `{"id": "a", "intent": "limite"}` + "\n" +
+		`{"intent": "` + strings.Repeat("x", maxStreamLine) + `"}` + "\n" +
+		`{"id": "c", "intent": "boleto"}` + "\r\n"
+	rec := httptest.NewRecorder()
+	h.StreamFindServiceHandler(rec, httptest.NewRequest(http.MethodPost, "/api/find-service/stream", strings.NewReader(body)))
+
+	results := map[string]util.BatchResult{}
+	dec := json.NewDecoder(rec.Body)
+	for dec.More() {
+		var result util.BatchResult
+		if err := dec.Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		results[result.ID] = result
+	}
+	if len(results) != 3 {
+		t.Fatalf("resultados = %+v, want 3", results)
+	}
+	if r := results["2"]; r.ErrorCode != util.ErrInvalidBody {
+		t.Errorf("linha longa: %+v, want INVALID_BODY", r)
+	}
+	for _, id := range []string{"a", "c"} {
+		if !results[id].Success {
+			t.Errorf("linha %s: %+v, want sucesso", id, results[id])
+		}
+	}
+}
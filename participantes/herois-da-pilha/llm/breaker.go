@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"sync"
+	"time"
+)
+
+// Estados do circuit breaker.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// Breaker é um circuit breaker simples: após FailureThreshold falhas
+// consecutivas ele abre e rejeita chamadas por Cooldown; depois disso deixa
+// passar uma chamada de teste (half-open), que fecha o circuito se tiver
+// sucesso ou o reabre se falhar.
+type Breaker struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// Allow indica se uma chamada pode ser feita agora. Quando retorna true, o
+// resultado deve ser informado com Success ou Failure (ou a chamada
+// devolvida com Release).
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.FailureThreshold {
+		return true
+	}
+	if time.Since(b.openedAt) < b.Cooldown || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// Release devolve uma chamada autorizada por Allow sem registrar resultado,
+// como quando o solicitante a cancela: o cancelamento não diz nada sobre o
+// provedor, mas a vaga da chamada de teste (half-open) precisa ser liberada.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// Success registra uma chamada bem-sucedida, fechando o circuito.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure registra uma falha, abrindo o circuito ao atingir o limite.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.failures >= b.FailureThreshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// State retorna o estado atual do circuito.
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.failures < b.FailureThreshold:
+		return BreakerClosed
+	case time.Since(b.openedAt) < b.Cooldown:
+		return BreakerOpen
+	default:
+		return BreakerHalfOpen
+	}
+}
@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerTripsAndRecovers(t *testing.T) {
+	b := &Breaker{FailureThreshold: 2, Cooldown: 20 * time.Millisecond}
+
+	b.Failure()
+	if !b.Allow() || b.State() != BreakerClosed {
+		t.Fatalf("uma falha não deveria abrir o circuito (estado %s)", b.State())
+	}
+	b.Failure()
+	if b.Allow() || b.State() != BreakerOpen {
+		t.Fatalf("o circuito deveria abrir no limite de falhas (estado %s)", b.State())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("estado após o cooldown = %s, want %s", b.State(), BreakerHalfOpen)
+	}
+	if !b.Allow() {
+		t.Fatal("o half-open deveria deixar passar a chamada de teste")
+	}
+	if b.Allow() {
+		t.Fatal("o half-open deveria deixar passar apenas uma chamada de teste")
+	}
+
+	b.Success()
+	if b.State() != BreakerClosed || !b.Allow() || !b.Allow() {
+		t.Fatalf("o sucesso da chamada de teste deveria fechar o circuito (estado %s)", b.State())
+	}
+}
+
+func TestBreakerHalfOpenFailureReopens(t *testing.T) {
+	b := &Breaker{FailureThreshold: 1, Cooldown: 20 * time.Millisecond}
+	b.Failure()
+	time.Sleep(30 * time.Millisecond)
+
+	if !b.Allow() {
+		t.Fatal("o half-open deveria deixar passar a chamada de teste")
+	}
+	b.Failure()
+	if b.State() != BreakerOpen || b.Allow() {
+		t.Fatalf("a falha da chamada de teste deveria reabrir o circuito (estado %s)", b.State())
+	}
+}
+
+func TestBreakerReleaseFreesHalfOpenSlot(t *testing.T) {
+	b := &Breaker{FailureThreshold: 1, Cooldown: 20 * time.Millisecond}
+	b.Failure()
+	time.Sleep(30 * time.Millisecond)
+
+	if !b.Allow() {
+		t.Fatal("o half-open deveria deixar passar a chamada de teste")
+	}
+	b.Release()
+	if !b.Allow() {
+		t.Fatal("Release deveria liberar a vaga da chamada de teste")
+	}
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("Release não deveria mudar o estado (estado %s)", b.State())
+	}
+}
@@ -0,0 +1,198 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"herois-da-pilha/util"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// DefaultBaseURL é a URL da API do OpenRouter.
+const DefaultBaseURL = "https://openrouter.ai/api/v1"
+
+// Config descreve a lista ordenada de provedores e o circuit breaker.
+//
+// Exemplo de arquivo (LLM_CONFIG_PATH):
+//
+//	{
+//	  "providers": [
+//	    {"model": "openai/gpt-4o-mini", "timeout": "5s"},
+//	    {"name": "gemini", "model": "google/gemini-2.0-flash-001"}
+//	  ],
+//	  "breaker": {"failure_threshold": 3, "cooldown": "30s"},
+//	  "hedge": {"enabled": true, "model": "openai/gpt-4o-mini", "percentile": 0.9}
+//	}
+type Config struct {
+	Providers []ProviderConfig `json:"providers"`
+	Breaker   BreakerConfig    `json:"breaker"`
+	Hedge     HedgeConfig      `json:"hedge"`
+}
+
+// HedgeConfig configura as chamadas especulativas (veja Hedged).
+type HedgeConfig struct {
+	Enabled bool `json:"enabled"`
+	// Model é o modelo do hedge; vazio usa a mesma lista de provedores.
+	Model      string   `json:"model"`
+	Percentile float64  `json:"percentile"`
+	MinDelay   Duration `json:"min_delay"`
+	MaxDelay   Duration `json:"max_delay"`
+}
+
+// ProviderConfig configura um provedor. Campos vazios usam os defaults do
+// ambiente (OpenRouter, OPENROUTER_API_KEY, LLM_TIMEOUT).
+type ProviderConfig struct {
+	Name      string   `json:"name"`
+	BaseURL   string   `json:"base_url"`
+	Model     string   `json:"model"`
+	APIKeyEnv string   `json:"api_key_env"`
+	Timeout   Duration `json:"timeout"`
+}
+
+// BreakerConfig configura os circuit breakers dos provedores.
+type BreakerConfig struct {
+	FailureThreshold int      `json:"failure_threshold"`
+	Cooldown         Duration `json:"cooldown"`
+}
+
+// Duration é um time.Duration serializado como texto ("5s", "1m").
+type Duration time.Duration
+
+// UnmarshalJSON implementa json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// ConfigFromEnv lê a configuração do arquivo em LLM_CONFIG_PATH ou, na
+// ausência dele, das variáveis de ambiente:
+//
+//	LLM_MODELS            modelos em ordem de preferência (default "openai/gpt-4o-mini")
+//	LLM_BASE_URL          URL da API (default OpenRouter)
+//	LLM_TIMEOUT           limite por chamada a cada provedor (default 6s)
+//	LLM_BREAKER_FAILURES  falhas consecutivas para abrir o circuito (default 3)
+//	LLM_BREAKER_COOLDOWN  tempo com o circuito aberto (default 30s)
+//	LLM_HEDGE             ativa as chamadas especulativas (default false)
+//	LLM_HEDGE_MODEL       modelo do hedge (default: a mesma lista de provedores)
+//	LLM_HEDGE_PERCENTILE  percentil da latência usado como atraso (default 0.9)
+//	LLM_HEDGE_MIN_DELAY   atraso mínimo (default 300ms)
+//	LLM_HEDGE_MAX_DELAY   atraso máximo e inicial (default 3s)
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Breaker: BreakerConfig{
+			FailureThreshold: util.GetEnvInt("LLM_BREAKER_FAILURES", 3),
+			Cooldown:         Duration(util.GetEnvDuration("LLM_BREAKER_COOLDOWN", 30*time.Second)),
+		},
+		Hedge: HedgeConfig{
+			Enabled:    util.GetEnvBool("LLM_HEDGE", false),
+			Model:      util.GetEnv("LLM_HEDGE_MODEL", ""),
+			Percentile: util.GetEnvFloat("LLM_HEDGE_PERCENTILE", 0.9),
+			MinDelay:   Duration(util.GetEnvDuration("LLM_HEDGE_MIN_DELAY", 300*time.Millisecond)),
+			MaxDelay:   Duration(util.GetEnvDuration("LLM_HEDGE_MAX_DELAY", 3*time.Second)),
+		},
+	}
+
+	if path := util.GetEnv("LLM_CONFIG_PATH", ""); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("erro ao ler configuração de IA: %w", err)
+		}
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return Config{}, fmt.Errorf("erro ao decodificar configuração de IA: %w", err)
+		}
+	} else {
+		for _, model := range strings.Split(util.GetEnv("LLM_MODELS", "openai/gpt-4o-mini"), ",") {
+			if model = strings.TrimSpace(model); model != "" {
+				cfg.Providers = append(cfg.Providers, ProviderConfig{Model: model})
+			}
+		}
+	}
+
+	if len(cfg.Providers) == 0 {
+		return Config{}, fmt.Errorf("nenhum provedor de IA configurado")
+	}
+	return cfg, nil
+}
+
+// Build cria o Provider descrito pela configuração: um Failover sobre os
+// provedores, envolvido por um Hedged se o hedge estiver ativo. accept valida
+// as respostas na disputa do hedge (nil aceita qualquer resposta).
+func (cfg Config) Build(accept func(openai.ChatCompletionResponse) bool) Provider {
+	providers := make([]Provider, 0, len(cfg.Providers))
+	for _, pc := range cfg.Providers {
+		providers = append(providers, pc.build())
+	}
+
+	breaker := cfg.Breaker
+	if breaker.FailureThreshold <= 0 {
+		breaker.FailureThreshold = 3
+	}
+	if breaker.Cooldown <= 0 {
+		breaker.Cooldown = Duration(30 * time.Second)
+	}
+	primary := NewFailover(breaker, providers...)
+
+	if !cfg.Hedge.Enabled {
+		return primary
+	}
+
+	var hedge Provider = primary
+	if cfg.Hedge.Model != "" {
+		hedge = NewFailover(breaker, ProviderConfig{Name: "hedge:" + cfg.Hedge.Model, Model: cfg.Hedge.Model}.build())
+	}
+
+	percentile := cfg.Hedge.Percentile
+	if percentile <= 0 || percentile > 1 {
+		percentile = 0.9
+	}
+	slog.Info("hedge ativo", "percentile", percentile,
+		"min_delay", time.Duration(cfg.Hedge.MinDelay).String(), "max_delay", time.Duration(cfg.Hedge.MaxDelay).String())
+
+	return &Hedged{
+		Primary:    primary,
+		Hedge:      hedge,
+		Percentile: percentile,
+		MinDelay:   time.Duration(cfg.Hedge.MinDelay),
+		MaxDelay:   time.Duration(cfg.Hedge.MaxDelay),
+		Accept:     accept,
+	}
+}
+
+// build cria o OpenAIProvider, completando os campos vazios com os defaults.
+func (pc ProviderConfig) build() *OpenAIProvider {
+	keyEnv := pc.APIKeyEnv
+	if keyEnv == "" {
+		keyEnv = "OPENROUTER_API_KEY"
+	}
+	apiKey := os.Getenv(keyEnv)
+	if apiKey == "" {
+		slog.Warn("variável da chave da API não definida", "env", keyEnv)
+	}
+
+	baseURL := pc.BaseURL
+	if baseURL == "" {
+		baseURL = util.GetEnv("LLM_BASE_URL", DefaultBaseURL)
+	}
+
+	timeout := time.Duration(pc.Timeout)
+	if timeout == 0 {
+		timeout = util.GetEnvDuration("LLM_TIMEOUT", 6*time.Second)
+	}
+
+	p := NewOpenAIProvider(pc.Name, apiKey, baseURL, pc.Model, timeout)
+	slog.Info("provedor de IA configurado", "provider", p.Name(), "model", pc.Model, "base_url", baseURL)
+	return p
+}
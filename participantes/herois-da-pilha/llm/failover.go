@@ -0,0 +1,118 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ErrAllProvidersFailed indica que nenhum provedor conseguiu atender a chamada.
+var ErrAllProvidersFailed = errors.New("todos os provedores de IA falharam")
+
+// Failover é um Provider que tenta os provedores em ordem, passando ao
+// próximo em caso de erro (incluindo 429 e timeout). Provedores com o
+// circuit breaker aberto são pulados.
+type Failover struct {
+	members []member
+}
+
+type member struct {
+	provider Provider
+	breaker  *Breaker
+}
+
+// NewFailover cria um Failover com um Breaker independente por provedor.
+func NewFailover(breaker BreakerConfig, providers ...Provider) *Failover {
+	f := &Failover{}
+	for _, p := range providers {
+		f.members = append(f.members, member{
+			provider: p,
+			breaker:  &Breaker{FailureThreshold: breaker.FailureThreshold, Cooldown: time.Duration(breaker.Cooldown)},
+		})
+	}
+	return f
+}
+
+// Name implementa Provider.
+func (f *Failover) Name() string {
+	names := make([]string, len(f.members))
+	for i, m := range f.members {
+		names[i] = m.provider.Name()
+	}
+	return "failover(" + strings.Join(names, ",") + ")"
+}
+
+// CreateChatCompletion implementa Provider.
+func (f *Failover) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	var errs []error
+	for _, m := range f.members {
+		if !m.breaker.Allow() {
+			errs = append(errs, fmt.Errorf("%s: circuit breaker aberto", m.provider.Name()))
+			continue
+		}
+
+		resp, err := m.provider.CreateChatCompletion(ctx, req)
+		if err == nil {
+			m.breaker.Success()
+			return resp, nil
+		}
+
+		// Cancelamento do solicitante não é falha do provedor
+		if ctx.Err() != nil {
+			m.breaker.Release()
+			return openai.ChatCompletionResponse{}, ctx.Err()
+		}
+
+		m.breaker.Failure()
+		slog.WarnContext(ctx, "falha no provedor de IA", "provider", m.provider.Name(), "error", err, "breaker", m.breaker.State())
+		errs = append(errs, fmt.Errorf("%s: %w", m.provider.Name(), err))
+	}
+
+	return openai.ChatCompletionResponse{}, fmt.Errorf("%w: %w", ErrAllProvidersFailed, errors.Join(errs...))
+}
+
+// HasAPIKey implementa Prober: verdadeiro se algum provedor tiver chave.
+func (f *Failover) HasAPIKey() bool {
+	for _, m := range f.members {
+		if p, ok := m.provider.(Prober); ok && p.HasAPIKey() {
+			return true
+		}
+	}
+	return false
+}
+
+// Probe implementa Prober: sucesso se algum provedor com o circuito não
+// aberto estiver acessível.
+func (f *Failover) Probe(ctx context.Context) error {
+	var errs []error
+	for _, m := range f.members {
+		p, ok := m.provider.(Prober)
+		if !ok {
+			continue
+		}
+		if m.breaker.State() == BreakerOpen {
+			errs = append(errs, fmt.Errorf("%s: circuit breaker aberto", m.provider.Name()))
+			continue
+		}
+		err := p.Probe(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", m.provider.Name(), err))
+	}
+	return errors.Join(errs...)
+}
+
+// BreakerStates retorna o estado do circuit breaker de cada provedor.
+func (f *Failover) BreakerStates() map[string]string {
+	states := make(map[string]string, len(f.members))
+	for _, m := range f.members {
+		states[m.provider.Name()] = m.breaker.State()
+	}
+	return states
+}
@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// fakeProvider responde com o conteúdo de content ou falha com err, após
+// delay (respeitando o cancelamento de ctx, contado em canceled).
+type fakeProvider struct {
+	name     string
+	content  string
+	err      error
+	delay    time.Duration
+	calls    atomic.Int32
+	canceled atomic.Int32
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) CreateChatCompletion(ctx context.Context, _ openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	p.calls.Add(1)
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		p.canceled.Add(1)
+		return openai.ChatCompletionResponse{}, ctx.Err()
+	}
+	if p.err != nil {
+		return openai.ChatCompletionResponse{}, p.err
+	}
+	return openai.ChatCompletionResponse{
+		Model:   p.name,
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: p.content}}},
+	}, nil
+}
+
+func TestFailoverFallsThroughAndSkipsOpenBreaker(t *testing.T) {
+	bad := &fakeProvider{name: "bad", err: errors.New("429")}
+	good := &fakeProvider{name: "good", content: "ok"}
+	f := NewFailover(BreakerConfig{FailureThreshold: 1, Cooldown: Duration(time.Hour)}, bad, good)
+
+	for i := 0; i < 3; i++ {
+		resp, err := f.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+		if err != nil || resp.Model != "good" {
+			t.Fatalf("chamada %d: resp %q, err %v; want o segundo provedor", i, resp.Model, err)
+		}
+	}
+	if n := bad.calls.Load(); n != 1 {
+		t.Errorf("o provedor com o circuito aberto foi chamado %d vezes, want 1", n)
+	}
+	if states := f.BreakerStates(); states["bad"] != BreakerOpen || states["good"] != BreakerClosed {
+		t.Errorf("estados = %v", states)
+	}
+}
+
+func TestFailoverAllFail(t *testing.T) {
+	f := NewFailover(BreakerConfig{FailureThreshold: 5, Cooldown: Duration(time.Hour)},
+		&fakeProvider{name: "a", err: errors.New("x")}, &fakeProvider{name: "b", err: errors.New("y")})
+
+	_, err := f.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	if !errors.Is(err, ErrAllProvidersFailed) {
+		t.Fatalf("err = %v, want ErrAllProvidersFailed", err)
+	}
+}
+
+// Um cancelamento do solicitante durante a chamada de teste não pode deixar
+// o circuito preso em half-open.
+func TestFailoverCancelledTrialCall(t *testing.T) {
+	p := &fakeProvider{name: "p", err: errors.New("x")}
+	f := NewFailover(BreakerConfig{FailureThreshold: 1, Cooldown: Duration(20 * time.Millisecond)}, p)
+
+	f.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	time.Sleep(30 * time.Millisecond)
+
+	p.err, p.content, p.delay = nil, "ok", time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.CreateChatCompletion(ctx, openai.ChatCompletionRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+
+	p.delay = 0
+	if _, err := f.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{}); err != nil {
+		t.Fatalf("chamada após o cancelamento: %v", err)
+	}
+	if state := f.BreakerStates()["p"]; state != BreakerClosed {
+		t.Errorf("estado = %s, want %s", state, BreakerClosed)
+	}
+}
@@ -0,0 +1,220 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// HedgeStats são os contadores de um Hedged.
+type HedgeStats struct {
+	// Fired conta as chamadas de hedge disparadas.
+	Fired uint64
+	// Won conta as vezes em que a resposta do hedge foi usada enquanto a
+	// chamada primária ainda estava em andamento.
+	Won uint64
+}
+
+// Hedged é um Provider que reduz a latência de cauda: se a chamada primária
+// não responder dentro de um atraso derivado do percentil de latência
+// recente (ou falhar antes disso), dispara uma segunda chamada no provedor de
+// hedge e usa a primeira resposta aceita, cancelando a outra.
+type Hedged struct {
+	Primary Provider
+	Hedge   Provider
+	// Percentile (0 a 1) da latência recente da primária usado como atraso.
+	Percentile float64
+	// MinDelay e MaxDelay limitam o atraso; MaxDelay também é usado enquanto
+	// não há amostras suficientes.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	// Accept valida a resposta (ex: JSON com service_id válido). Respostas
+	// rejeitadas não encerram a disputa. nil aceita qualquer resposta.
+	Accept func(openai.ChatCompletionResponse) bool
+
+	latencies latencyWindow
+	fired     atomic.Uint64
+	won       atomic.Uint64
+}
+
+// ErrRejected indica uma resposta recusada por Accept. Quando nenhuma
+// resposta é aceita, o erro retornado o inclui.
+var ErrRejected = errors.New("resposta inválida do modelo")
+
+type hedgeResult struct {
+	resp  openai.ChatCompletionResponse
+	err   error
+	hedge bool
+}
+
+// Name implementa Provider.
+func (h *Hedged) Name() string {
+	return fmt.Sprintf("hedged(%s,%s)", h.Primary.Name(), h.Hedge.Name())
+}
+
+// CreateChatCompletion implementa Provider.
+func (h *Hedged) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	// Cancela a chamada perdedora ao retornar
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult, 2)
+	call := func(p Provider, hedge bool) {
+		resp, err := p.CreateChatCompletion(ctx, req)
+		if err == nil && h.Accept != nil && !h.Accept(resp) {
+			err = ErrRejected
+		}
+		results <- hedgeResult{resp: resp, err: err, hedge: hedge}
+	}
+
+	start := time.Now()
+	go call(h.Primary, false)
+
+	timer := time.NewTimer(h.delay())
+	defer timer.Stop()
+
+	var primaryErr error
+	select {
+	case r := <-results:
+		if r.err == nil {
+			h.latencies.add(time.Since(start))
+			return r.resp, nil
+		}
+		// Falhou antes do atraso: dispara o hedge imediatamente
+		primaryErr = r.err
+	case <-timer.C:
+	case <-ctx.Done():
+		return openai.ChatCompletionResponse{}, ctx.Err()
+	}
+
+	// O timer pode vencer a disputa com um cancelamento simultâneo: ninguém
+	// esperaria pelo hedge
+	if err := ctx.Err(); err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	h.fired.Add(1)
+	go call(h.Hedge, true)
+
+	primaryPending := primaryErr == nil
+	outstanding := 2
+	if !primaryPending {
+		outstanding = 1
+	}
+
+	var errs []error
+	if primaryErr != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", h.Primary.Name(), primaryErr))
+	}
+	for ; outstanding > 0; outstanding-- {
+		var r hedgeResult
+		select {
+		case r = <-results:
+		case <-ctx.Done():
+			return openai.ChatCompletionResponse{}, ctx.Err()
+		}
+		if r.err == nil {
+			// A latência da primária é registrada mesmo quando o hedge vence
+			// (como limite inferior), para o percentil não ficar otimista.
+			h.latencies.add(time.Since(start))
+			if r.hedge && primaryPending {
+				h.won.Add(1)
+			}
+			return r.resp, nil
+		}
+		name := h.Primary.Name()
+		if r.hedge {
+			name = h.Hedge.Name()
+		} else {
+			primaryPending = false
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", name, r.err))
+	}
+
+	return openai.ChatCompletionResponse{}, errors.Join(errs...)
+}
+
+// delay calcula o atraso até disparar o hedge.
+func (h *Hedged) delay() time.Duration {
+	d, ok := h.latencies.percentile(h.Percentile)
+	if !ok {
+		return h.MaxDelay
+	}
+	return min(max(d, h.MinDelay), h.MaxDelay)
+}
+
+// HedgeStats retorna os contadores de hedge.
+func (h *Hedged) HedgeStats() HedgeStats {
+	return HedgeStats{Fired: h.fired.Load(), Won: h.won.Load()}
+}
+
+// HasAPIKey implementa Prober, delegando à primária.
+func (h *Hedged) HasAPIKey() bool {
+	if p, ok := h.Primary.(Prober); ok {
+		return p.HasAPIKey()
+	}
+	return true
+}
+
+// Probe implementa Prober, delegando à primária.
+func (h *Hedged) Probe(ctx context.Context) error {
+	if p, ok := h.Primary.(Prober); ok {
+		return p.Probe(ctx)
+	}
+	return nil
+}
+
+// BreakerStates retorna os estados dos circuit breakers da primária e do hedge.
+func (h *Hedged) BreakerStates() map[string]string {
+	states := make(map[string]string)
+	for _, p := range []Provider{h.Primary, h.Hedge} {
+		if r, ok := p.(interface{ BreakerStates() map[string]string }); ok {
+			for name, state := range r.BreakerStates() {
+				states[name] = state
+			}
+		}
+	}
+	return states
+}
+
+// latencyWindow guarda as latências mais recentes em um buffer circular.
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples [128]time.Duration
+	n       int
+	next    int
+}
+
+// minSamples é o mínimo de amostras para o percentil ser considerado.
+const minSamples = 10
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % len(w.samples)
+	if w.n < len(w.samples) {
+		w.n++
+	}
+}
+
+func (w *latencyWindow) percentile(p float64) (time.Duration, bool) {
+	w.mu.Lock()
+	if w.n < minSamples {
+		w.mu.Unlock()
+		return 0, false
+	}
+	sorted := make([]time.Duration, w.n)
+	copy(sorted, w.samples[:w.n])
+	w.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(p * float64(len(sorted)-1))
+	return sorted[min(max(idx, 0), len(sorted)-1)], true
+}
@@ -0,0 +1,150 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func newHedged(primary, hedge Provider) *Hedged {
+	return &Hedged{
+		Primary:    primary,
+		Hedge:      hedge,
+		Percentile: 0.9,
+		MinDelay:   10 * time.Millisecond,
+		MaxDelay:   50 * time.Millisecond,
+		Accept:     func(r openai.ChatCompletionResponse) bool { return r.Choices[0].Message.Content == "ok" },
+	}
+}
+
+// waitFor espera cond ficar verdadeira, já que as chamadas perdedoras
+// terminam depois do retorno de CreateChatCompletion.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("tempo esgotado esperando: %s", what)
+}
+
+func TestHedgedDelay(t *testing.T) {
+	h := newHedged(nil, nil)
+	if d := h.delay(); d != h.MaxDelay {
+		t.Errorf("sem amostras: atraso = %v, want MaxDelay", d)
+	}
+
+	for i := 1; i <= minSamples; i++ {
+		h.latencies.add(time.Duration(i) * 2 * time.Millisecond)
+	}
+	// p90 de 2ms..20ms é 18ms, dentro dos limites
+	if d := h.delay(); d != 18*time.Millisecond {
+		t.Errorf("atraso = %v, want 18ms", d)
+	}
+
+	h.Percentile = 0
+	if d := h.delay(); d != h.MinDelay {
+		t.Errorf("percentil abaixo do mínimo: atraso = %v, want MinDelay", d)
+	}
+	h.Percentile = 1
+	h.MaxDelay = 15 * time.Millisecond
+	if d := h.delay(); d != h.MaxDelay {
+		t.Errorf("percentil acima do máximo: atraso = %v, want MaxDelay", d)
+	}
+}
+
+func TestHedgedFastPrimaryDoesNotFire(t *testing.T) {
+	primary := &fakeProvider{name: "p", content: "ok"}
+	hedge := &fakeProvider{name: "h", content: "ok"}
+	h := newHedged(primary, hedge)
+
+	resp, err := h.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	if err != nil || resp.Model != "p" {
+		t.Fatalf("resp %q, err %v; want a primária", resp.Model, err)
+	}
+	if stats := h.HedgeStats(); stats != (HedgeStats{}) || hedge.calls.Load() != 0 {
+		t.Errorf("o hedge não deveria disparar: %+v, %d chamadas", stats, hedge.calls.Load())
+	}
+}
+
+func TestHedgedWinsAndCancelsPrimary(t *testing.T) {
+	primary := &fakeProvider{name: "p", content: "ok", delay: time.Second}
+	hedge := &fakeProvider{name: "h", content: "ok"}
+	h := newHedged(primary, hedge)
+
+	resp, err := h.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	if err != nil || resp.Model != "h" {
+		t.Fatalf("resp %q, err %v; want o hedge", resp.Model, err)
+	}
+	if stats := h.HedgeStats(); stats != (HedgeStats{Fired: 1, Won: 1}) {
+		t.Errorf("contadores = %+v, want Fired 1, Won 1", stats)
+	}
+	waitFor(t, "cancelamento da primária", func() bool { return primary.canceled.Load() == 1 })
+}
+
+func TestHedgedPrimaryWinsAndCancelsHedge(t *testing.T) {
+	primary := &fakeProvider{name: "p", content: "ok", delay: 80 * time.Millisecond}
+	hedge := &fakeProvider{name: "h", content: "ok", delay: time.Second}
+	h := newHedged(primary, hedge)
+
+	resp, err := h.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	if err != nil || resp.Model != "p" {
+		t.Fatalf("resp %q, err %v; want a primária", resp.Model, err)
+	}
+	if stats := h.HedgeStats(); stats != (HedgeStats{Fired: 1}) {
+		t.Errorf("contadores = %+v, want Fired 1, Won 0", stats)
+	}
+	waitFor(t, "cancelamento do hedge", func() bool { return hedge.canceled.Load() == 1 })
+}
+
+func TestHedgedPrimaryFailureFiresImmediately(t *testing.T) {
+	primary := &fakeProvider{name: "p", err: errors.New("429")}
+	hedge := &fakeProvider{name: "h", content: "ok"}
+	h := newHedged(primary, hedge)
+	h.MinDelay, h.MaxDelay = time.Second, time.Second
+
+	start := time.Now()
+	resp, err := h.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	if err != nil || resp.Model != "h" {
+		t.Fatalf("resp %q, err %v; want o hedge", resp.Model, err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("o hedge deveria disparar sem esperar o atraso (levou %v)", elapsed)
+	}
+	// O hedge não venceu uma disputa: a primária já tinha falhado
+	if stats := h.HedgeStats(); stats != (HedgeStats{Fired: 1}) {
+		t.Errorf("contadores = %+v, want Fired 1, Won 0", stats)
+	}
+}
+
+func TestHedgedDoesNotFireAfterCancel(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		primary := &fakeProvider{name: "p", content: "ok", delay: time.Second}
+		hedge := &fakeProvider{name: "h", content: "ok"}
+		h := newHedged(primary, hedge)
+		h.MinDelay, h.MaxDelay = 0, 0
+
+		// Timer e cancelamento ficam prontos ao mesmo tempo
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := h.CreateChatCompletion(ctx, openai.ChatCompletionRequest{}); !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+		if stats := h.HedgeStats(); stats.Fired != 0 || hedge.calls.Load() != 0 {
+			t.Fatalf("hedge disparado após o cancelamento: %+v, %d chamadas", stats, hedge.calls.Load())
+		}
+	}
+}
+
+func TestHedgedRejectedResponses(t *testing.T) {
+	h := newHedged(&fakeProvider{name: "p", content: "lixo"}, &fakeProvider{name: "h", content: "lixo"})
+
+	_, err := h.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	if !errors.Is(err, ErrRejected) {
+		t.Fatalf("err = %v, want ErrRejected", err)
+	}
+}
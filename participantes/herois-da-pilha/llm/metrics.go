@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"herois-da-pilha/metrics"
+	"herois-da-pilha/util"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+var (
+	upstreamDuration = metrics.NewHistogramVec("ivr_upstream_request_duration_seconds",
+		"Latência das chamadas à API de IA, por provedor e resultado.", metrics.DefBuckets, "provider", "outcome")
+	tokensTotal = metrics.NewCounterVec("ivr_llm_tokens_total",
+		"Tokens consumidos na API de IA, por modelo e tipo (prompt/completion).", "model", "type")
+	costTotal = metrics.NewCounterVec("ivr_llm_cost_usd_total",
+		"Custo estimado das chamadas à API de IA em dólares, por modelo (veja LLM_PRICES).", "model")
+)
+
+// price é o preço em dólares por milhão de tokens de entrada e de saída.
+type price struct {
+	input, output float64
+}
+
+// defaultPrices são os preços de tabela do OpenRouter dos modelos padrão.
+var defaultPrices = map[string]price{
+	"openai/gpt-4o-mini":          {input: 0.15, output: 0.60},
+	"google/gemini-2.0-flash-001": {input: 0.10, output: 0.40},
+}
+
+// prices combina defaultPrices com LLM_PRICES, uma lista "modelo=entrada:saída"
+// separada por vírgulas, em dólares por milhão de tokens
+// (ex: "openai/gpt-4o-mini=0.15:0.60"). Modelos sem preço não geram custo.
+var prices = parsePrices(util.GetEnv("LLM_PRICES", ""))
+
+func parsePrices(spec string) map[string]price {
+	result := make(map[string]price, len(defaultPrices))
+	for model, p := range defaultPrices {
+		result[model] = p
+	}
+	for _, item := range strings.Split(spec, ",") {
+		model, values, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			continue
+		}
+		in, out, _ := strings.Cut(values, ":")
+		input, err1 := strconv.ParseFloat(in, 64)
+		output, err2 := strconv.ParseFloat(out, 64)
+		if err1 != nil || err2 != nil {
+			slog.Warn("preço inválido em LLM_PRICES", "item", item)
+			continue
+		}
+		result[model] = price{input: input, output: output}
+	}
+	return result
+}
+
+// observeCall registra a latência, os tokens e o custo de uma chamada.
+func observeCall(ctx context.Context, provider, model string, elapsed time.Duration, resp openai.ChatCompletionResponse, err error) {
+	outcome := "success"
+	switch {
+	case err == nil:
+	case errors.Is(err, context.Canceled) && ctx.Err() != nil:
+		outcome = "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		outcome = "timeout"
+	default:
+		outcome = "error"
+	}
+	upstreamDuration.With(provider, outcome).Observe(elapsed.Seconds())
+	if err != nil {
+		return
+	}
+
+	usage := resp.Usage
+	tokensTotal.With(model, "prompt").Add(float64(usage.PromptTokens))
+	tokensTotal.With(model, "completion").Add(float64(usage.CompletionTokens))
+	if p, ok := prices[model]; ok {
+		costTotal.With(model).Add((float64(usage.PromptTokens)*p.input + float64(usage.CompletionTokens)*p.output) / 1e6)
+	}
+}
@@ -0,0 +1,139 @@
+// Package llm abstrai os provedores de modelos de linguagem usados na
+// classificação. Todos falam a API de chat completion compatível com OpenAI
+// (OpenRouter e afins); Failover combina vários provedores/modelos em ordem
+// de preferência, com circuit breaker por provedor.
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"herois-da-pilha/tracing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Provider executa chamadas de chat completion em um provedor/modelo.
+type Provider interface {
+	// Name identifica o provedor em logs e métricas.
+	Name() string
+	// CreateChatCompletion executa a chamada. O campo Model da requisição é
+	// definido pelo provedor.
+	CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+}
+
+// Prober é implementado por provedores que podem verificar sua
+// disponibilidade sem consumir tokens.
+type Prober interface {
+	Probe(ctx context.Context) error
+	HasAPIKey() bool
+}
+
+// OpenAIProvider é um Provider para APIs compatíveis com OpenAI.
+type OpenAIProvider struct {
+	name    string
+	client  *openai.Client
+	model   string
+	apiKey  string
+	baseURL string
+	timeout time.Duration
+}
+
+// NewOpenAIProvider cria um provedor para o modelo em baseURL. timeout limita
+// cada chamada (0 = sem limite além do contexto).
+func NewOpenAIProvider(name, apiKey, baseURL, model string, timeout time.Duration) *OpenAIProvider {
+	config := openai.DefaultConfig(apiKey)
+	config.BaseURL = baseURL
+
+	if name == "" {
+		name = model
+	}
+
+	return &OpenAIProvider{
+		name:    name,
+		client:  openai.NewClientWithConfig(config),
+		model:   model,
+		apiKey:  apiKey,
+		baseURL: baseURL,
+		timeout: timeout,
+	}
+}
+
+// Name implementa Provider.
+func (p *OpenAIProvider) Name() string {
+	return p.name
+}
+
+// Model retorna o modelo usado pelo provedor.
+func (p *OpenAIProvider) Model() string {
+	return p.model
+}
+
+// CreateChatCompletion implementa Provider.
+func (p *OpenAIProvider) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	ctx, span := tracing.Start(ctx, "llm.chat_completion", tracing.KindClient)
+	defer span.End()
+	span.Set("llm.provider", p.name)
+	span.Set("gen_ai.request.model", p.model)
+
+	req.Model = p.model
+	start := time.Now()
+	resp, err := p.client.CreateChatCompletion(ctx, req)
+	observeCall(ctx, p.name, p.model, time.Since(start), resp, err)
+
+	if err != nil {
+		span.SetError(err)
+		return resp, err
+	}
+	span.Set("gen_ai.response.model", resp.Model)
+	span.Set("gen_ai.usage.input_tokens", resp.Usage.PromptTokens)
+	span.Set("gen_ai.usage.output_tokens", resp.Usage.CompletionTokens)
+	return resp, nil
+}
+
+// HasAPIKey implementa Prober.
+func (p *OpenAIProvider) HasAPIKey() bool {
+	return p.apiKey != ""
+}
+
+// Probe implementa Prober com uma chamada barata que valida a chave sem
+// consumir tokens: GET /key no OpenRouter e, nos demais provedores
+// compatíveis com OpenAI, GET /models.
+func (p *OpenAIProvider) Probe(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.baseURL, "/")+p.probePath(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("API inacessível: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API respondeu com status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// probePath retorna o endpoint da sonda, conforme o provedor em baseURL.
+func (p *OpenAIProvider) probePath() string {
+	if u, err := url.Parse(p.baseURL); err == nil && strings.HasSuffix(u.Hostname(), "openrouter.ai") {
+		return "/key"
+	}
+	return "/models"
+}
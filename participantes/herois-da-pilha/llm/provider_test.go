@@ -0,0 +1,33 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIProviderProbe(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if r.Header.Get("Authorization") != "Bearer k" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	if err := NewOpenAIProvider("", "k", srv.URL+"/v1", "m", 0).Probe(context.Background()); err != nil {
+		t.Fatalf("Probe: %v", err)
+	}
+	if path != "/v1/models" {
+		t.Errorf("provedor genérico sondou %q, want /v1/models", path)
+	}
+	if err := NewOpenAIProvider("", "x", srv.URL+"/v1", "m", 0).Probe(context.Background()); err == nil {
+		t.Error("Probe deveria falhar com a chave recusada")
+	}
+
+	if got := NewOpenAIProvider("", "k", "https://openrouter.ai/api/v1", "m", 0).probePath(); got != "/key" {
+		t.Errorf("OpenRouter: probePath = %q, want /key", got)
+	}
+}
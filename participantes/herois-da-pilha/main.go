@@ -1,47 +1,155 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
+	"herois-da-pilha/grpcapi"
 	"herois-da-pilha/handler"
+	"herois-da-pilha/metrics"
+	"herois-da-pilha/tracing"
+	"herois-da-pilha/util"
 )
 
 func main() {
+	// Logs estruturados em JSON (veja util.SetupLogger)
+	util.SetupLogger()
+	if err := tracing.SetupFromEnv(); err != nil {
+		slog.Warn("tracing desativado", "error", err)
+	}
+
 	// 1. Inicializar o Handler (que inicializa o serviço de IA e o cache)
-	apiHandler := handler.NewAPIHandler()
+	apiHandler, err := handler.NewAPIHandler()
+	if err != nil {
+		slog.Error("configuração inválida", "error", err)
+		os.Exit(1)
+	}
 
 	// 2. Configurar o Roteador (usando o ServeMux da biblioteca padrão)
 	mux := http.NewServeMux()
 
 	// O http.ServeMux usa HandleFunc
 	mux.HandleFunc("/api/find-service", apiHandler.FindServiceHandler)
+	mux.HandleFunc("/api/find-service/batch", apiHandler.BatchFindServiceHandler)
+	mux.HandleFunc("/api/find-service/stream", apiHandler.StreamFindServiceHandler)
 	mux.HandleFunc("/api/healthz", apiHandler.HealthCheckHandler)
+	mux.HandleFunc("/api/readyz", apiHandler.ReadinessHandler)
+	mux.HandleFunc("/api/stats", apiHandler.StatsHandler)
+	mux.HandleFunc("/api/sessions/", apiHandler.SessionHandler)
+	mux.HandleFunc("/api/admin/reload", apiHandler.ReloadHandler)
+	mux.Handle("/metrics", metrics.Default.Handler())
 
 	// 3. Ler a porta da variável de ambiente
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "18020"
-		fmt.Printf("AVISO: Variável de ambiente 'PORT' não definida, usando default: %s\n", port)
+		slog.Warn("variável de ambiente PORT não definida, usando default", "port", port)
 	}
 	addr := fmt.Sprintf(":%s", port)
 
 	// 4. Configurar e Iniciar o Servidor HTTP (Otimizado)
 	// Usar http.Server para definir timeouts, o que é uma boa prática
 	server := &http.Server{
-		Addr:    addr,
-		Handler: mux,
+		Addr:     addr,
+		Handler:  handler.WithRequestID(mux),
+		ErrorLog: slog.NewLogLogger(slog.Default().Handler(), slog.LevelError),
 		// Timeouts razoáveis para um serviço de baixa latência
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
-	fmt.Printf("Serviço Credsystem/Golang SP (net/http) rodando na porta %s...\n", port)
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("Falha ao iniciar o servidor: %v", err)
+	// 5. API gRPC numa porta separada (GRPC_PORT, default 18021; "0" desativa)
+	var grpcServer *grpcapi.Server
+	if grpcPort := util.GetEnv("GRPC_PORT", "18021"); grpcPort != "0" {
+		lis, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			slog.Error("falha ao abrir a porta gRPC", "port", grpcPort, "error", err)
+			os.Exit(1)
+		}
+		grpcServer = grpcapi.NewServer(apiHandler.FinderService, apiHandler.MaxBatchSize)
+		go func() {
+			slog.Info("API gRPC rodando", "port", grpcPort)
+			if err := grpcServer.Serve(lis); err != nil {
+				slog.Error("servidor gRPC encerrado com erro", "error", err)
+			}
+		}()
 	}
+
+	// 6. SIGHUP recarrega catálogo, dataset e prompt sem reiniciar
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if _, err := apiHandler.FinderService.Reload(); err != nil {
+				slog.Error("reload via SIGHUP falhou", "error", err)
+			}
+		}
+	}()
+
+	// 7. Encerramento ordenado em SIGTERM/SIGINT (ex: docker compose down)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Serviço Credsystem/Golang SP (net/http) rodando", "port", port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if err != nil && err != http.ErrServerClosed {
+			slog.Error("falha ao iniciar o servidor", "error", err)
+			os.Exit(1)
+		}
+	case <-ctx.Done():
+	}
+	stop()
+
+	// O prazo total deve caber no stop_grace_period do Docker (default 10s)
+	timeout := util.GetEnvDuration("SHUTDOWN_TIMEOUT", 8*time.Second)
+	slog.Info("sinal de encerramento recebido, aguardando as requisições em andamento", "timeout", timeout.String())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Para de aceitar conexões e espera os handlers ativos terminarem. Os dois
+	// servidores encerram ao mesmo tempo, dividindo o prazo, e o health do gRPC
+	// passa a NOT_SERVING já no início, enquanto o HTTP ainda drena.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("erro ao encerrar o servidor HTTP", "error", err)
+		}
+	}()
+	if grpcServer != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			grpcServer.Shutdown(shutdownCtx)
+		}()
+	}
+	wg.Wait()
+
+	// Drena os jobs restantes, para os workers e persiste o cache
+	if err := apiHandler.FinderService.Close(shutdownCtx); err != nil {
+		slog.Warn("erro ao encerrar o serviço de classificação", "error", err)
+	}
+
+	if err := tracing.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("erro ao exportar os spans pendentes", "error", err)
+	}
+
+	slog.Info("serviço encerrado")
 }
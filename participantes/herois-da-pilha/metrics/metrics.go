@@ -0,0 +1,293 @@
+// Package metrics implementa contadores, gauges e histogramas com exposição
+// no formato texto do Prometheus, sem dependências externas (a imagem roda
+// com 128MB de memória).
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry guarda as métricas e as escreve no formato do Prometheus.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]collector
+}
+
+// collector é uma família de métricas com o mesmo nome.
+type collector interface {
+	write(w io.Writer, name string)
+}
+
+// NewRegistry cria um Registry vazio.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]collector)}
+}
+
+// Default é o Registry usado pelas funções New* do pacote.
+var Default = NewRegistry()
+
+// register adiciona a métrica, substituindo outra de mesmo nome.
+func (r *Registry) register(name, help, kind string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics[name] = described{help: help, kind: kind, collector: c}
+}
+
+type described struct {
+	collector
+	help string
+	kind string
+}
+
+func (d described) write(w io.Writer, name string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, d.help, name, d.kind)
+	d.collector.write(w, name)
+}
+
+// Write escreve todas as métricas, ordenadas por nome.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	collectors := make(map[string]collector, len(r.metrics))
+	for name, c := range r.metrics {
+		collectors[name] = c
+	}
+	r.mu.Unlock()
+
+	sort.Strings(names)
+	for _, name := range names {
+		collectors[name].write(w, name)
+	}
+}
+
+// Handler serve as métricas do Registry.
+// GET /metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// Counter é um contador monotônico de ponto flutuante.
+type Counter struct {
+	bits atomic.Uint64
+}
+
+// Inc soma 1.
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add soma v (deve ser não negativo).
+func (c *Counter) Add(v float64) {
+	for {
+		old := c.bits.Load()
+		if c.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Value retorna o valor atual.
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(c.bits.Load())
+}
+
+// CounterVec é uma família de contadores particionada por rótulos.
+type CounterVec struct {
+	vec[*Counter]
+}
+
+// NewCounterVec cria e registra em Default uma família de contadores.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec: newVec(labels, func() *Counter { return &Counter{} })}
+	Default.register(name, help, "counter", c)
+	return c
+}
+
+func (c *CounterVec) write(w io.Writer, name string) {
+	c.each(func(labels string, counter *Counter) {
+		fmt.Fprintf(w, "%s%s %s\n", name, labels, formatFloat(counter.Value()))
+	})
+}
+
+// Histogram conta observações em buckets cumulativos.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// Observe registra um valor.
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// HistogramVec é uma família de histogramas particionada por rótulos.
+type HistogramVec struct {
+	vec[*Histogram]
+}
+
+// DefBuckets são os buckets padrão de latência, em segundos.
+var DefBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// NewHistogramVec cria e registra em Default uma família de histogramas com
+// os buckets informados (limites superiores em ordem crescente).
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{vec: newVec(labels, func() *Histogram {
+		return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+	})}
+	Default.register(name, help, "histogram", h)
+	return h
+}
+
+func (h *HistogramVec) write(w io.Writer, name string) {
+	h.each(func(labels string, hist *Histogram) {
+		hist.mu.Lock()
+		defer hist.mu.Unlock()
+		for i, upper := range hist.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(labels, "le", formatFloat(upper)), hist.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(labels, "le", "+Inf"), hist.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatFloat(hist.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, labels, hist.count)
+	})
+}
+
+// valueFunc é uma métrica sem rótulos lida no momento da coleta.
+type valueFunc func() float64
+
+func (f valueFunc) write(w io.Writer, name string) {
+	fmt.Fprintf(w, "%s %s\n", name, formatFloat(f()))
+}
+
+// NewGaugeFunc registra em Default um gauge calculado por f a cada coleta.
+func NewGaugeFunc(name, help string, f func() float64) {
+	Default.register(name, help, "gauge", valueFunc(f))
+}
+
+// NewCounterFunc registra em Default um contador mantido em outro lugar
+// (ex: os contadores do cache), lido por f a cada coleta.
+func NewCounterFunc(name, help string, f func() float64) {
+	Default.register(name, help, "counter", valueFunc(f))
+}
+
+// vec mapeia combinações de valores de rótulos para as séries.
+type vec[T any] struct {
+	labels []string
+	newT   func() T
+
+	mu     sync.RWMutex
+	series map[string]T // chave: rótulos formatados ({a="x",b="y"})
+}
+
+func newVec[T any](labels []string, newT func() T) vec[T] {
+	return vec[T]{labels: labels, newT: newT, series: make(map[string]T)}
+}
+
+// With retorna a série com os valores de rótulos informados, na ordem em que
+// os rótulos foram declarados, criando-a se necessário.
+func (v *vec[T]) With(values ...string) T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %d valores para %d rótulos", len(values), len(v.labels)))
+	}
+	key := formatLabels(v.labels, values)
+
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok := v.series[key]; ok {
+		return s
+	}
+	s = v.newT()
+	v.series[key] = s
+	return s
+}
+
+// each percorre as séries em ordem de rótulos.
+func (v *vec[T]) each(f func(labels string, s T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	v.mu.RUnlock()
+
+	sort.Strings(keys)
+	for _, k := range keys {
+		v.mu.RLock()
+		s := v.series[k]
+		v.mu.RUnlock()
+		f(k, s)
+	}
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(name)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabel(values[i]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// withLabel acrescenta um rótulo aos rótulos já formatados.
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + escapeLabel(value) + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
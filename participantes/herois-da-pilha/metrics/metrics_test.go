@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryExposition(t *testing.T) {
+	saved := Default
+	Default = NewRegistry()
+	defer func() { Default = saved }()
+
+	requests := NewCounterVec("test_requests_total", "Requisições.", "outcome")
+	requests.With("success").Add(2)
+	requests.With("error").Inc()
+	requests.With(`com "aspas"`).Inc()
+
+	latency := NewHistogramVec("test_latency_seconds", "Latência.", []float64{0.1, 1}, "stage")
+	latency.With("llm").Observe(0.05)
+	latency.With("llm").Observe(0.5)
+	latency.With("llm").Observe(3)
+
+	NewGaugeFunc("test_queue_depth", "Fila.", func() float64 { return 7 })
+
+	var sb strings.Builder
+	Default.Write(&sb)
+
+	want := `# HELP test_latency_seconds Latência.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{stage="llm",le="0.1"} 1
+test_latency_seconds_bucket{stage="llm",le="1"} 2
+test_latency_seconds_bucket{stage="llm",le="+Inf"} 3
+test_latency_seconds_sum{stage="llm"} 3.55
+test_latency_seconds_count{stage="llm"} 3
+# HELP test_queue_depth Fila.
+# TYPE test_queue_depth gauge
+test_queue_depth 7
+# HELP test_requests_total Requisições.
+# TYPE test_requests_total counter
+test_requests_total{outcome="com \"aspas\""} 1
+test_requests_total{outcome="error"} 1
+test_requests_total{outcome="success"} 2
+`
+	if got := sb.String(); got != want {
+		t.Errorf("exposição incorreta:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCounterConcurrentAdd(t *testing.T) {
+	var c Counter
+	done := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		go func() {
+			for j := 0; j < 1000; j++ {
+				c.Add(0.5)
+			}
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		<-done
+	}
+	if got := c.Value(); got != 4000 {
+		t.Errorf("Value() = %v, want 4000", got)
+	}
+}
@@ -0,0 +1,88 @@
+// Package normalize padroniza textos de intenção em português antes do cache
+// e da classificação, para que variações triviais ("Perdi meu cartão",
+// "perdi meu cartao", "perdi  meu cartão!") resultem na mesma chave.
+package normalize
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// abbreviations mapeia abreviações e grafias informais comuns em PT-BR
+// (já sem acentos) para a forma por extenso. Letras isoladas não entram
+// aqui: "c", "p" ou "n" sozinhos também são letras de opção ou de siglas
+// (veja slashAbbreviation).
+var abbreviations = map[string]string{
+	"vc":      "voce",
+	"vcs":     "voces",
+	"pq":      "porque",
+	"oq":      "o que",
+	"naum":    "nao",
+	"eh":      "e",
+	"pra":     "para",
+	"pro":     "para o",
+	"tb":      "tambem",
+	"tbm":     "tambem",
+	"hj":      "hoje",
+	"qdo":     "quando",
+	"qnd":     "quando",
+	"qto":     "quanto",
+	"qt":      "quanto",
+	"mt":      "muito",
+	"mto":     "muito",
+	"td":      "tudo",
+	"msg":     "mensagem",
+	"cel":     "celular",
+	"cartaum": "cartao",
+	"crt":     "cartao",
+	"blz":     "beleza",
+	"obg":     "obrigado",
+	"vlw":     "valeu",
+	"pfv":     "por favor",
+	"pfvr":    "por favor",
+	"pf":      "por favor",
+}
+
+// slashAbbreviation casa as abreviações de uma letra com barra ("c/ juros",
+// "p/ pagamento"), a única forma em que não são ambíguas.
+var slashAbbreviation = regexp.MustCompile(`\b([cp])/`)
+
+var slashExpansions = map[string]string{"c/": "com ", "p/": "para "}
+
+// Normalize aplica, em ordem: minúsculas, remoção de acentos, troca de
+// pontuação e símbolos por espaço, expansão de abreviações e colapso de
+// espaços. A função é idempotente.
+func Normalize(text string) string {
+	text = FoldAccents(strings.ToLower(text))
+	text = slashAbbreviation.ReplaceAllStringFunc(text, func(m string) string { return slashExpansions[m] })
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, text)
+
+	words := strings.Fields(text)
+	for i, w := range words {
+		if expanded, ok := abbreviations[w]; ok {
+			words[i] = expanded
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// FoldAccents remove os acentos do texto, preservando o restante. O texto é
+// decomposto (NFD) e as marcas combinantes descartadas, de modo que "ã"
+// precomposto e "a" seguido de U+0303 resultam no mesmo "a".
+func FoldAccents(text string) string {
+	decomposed := norm.NFD.String(text)
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, decomposed)
+}
@@ -0,0 +1,65 @@
+package normalize
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"minúsculas e acentos", "Perdi meu cartão", "perdi meu cartao"},
+		{"sem acento", "perdi meu cartao", "perdi meu cartao"},
+		{"pontuação e espaços", "  perdi  meu cartão!  ", "perdi meu cartao"},
+		{"cedilha e til", "Não consigo fazer a DEVOLUÇÃO", "nao consigo fazer a devolucao"},
+		{"abreviações", "vc pode me ajudar pq perdi o crt", "voce pode me ajudar porque perdi o cartao"},
+		{"abreviação com barra", "boleto p/ pagamento", "boleto para pagamento"},
+		{"abreviação que expande em duas palavras", "oq faço", "o que faco"},
+		{"abreviação dentro de palavra não é expandida", "pqno vcto", "pqno vcto"},
+		{"números preservados", "cartão final 1234?", "cartao final 1234"},
+		{"acentos decompostos (NFD)", "Perdi meu carta\u0303o, na\u0303o sei a senha", "perdi meu cartao nao sei a senha"},
+		{"cedilha decomposta", "devoluc\u0327a\u0303o", "devolucao"},
+		{"abreviação com barra sem espaço", "compra c/juros", "compra com juros"},
+		{"letra de opção preservada", "quero a opção c", "quero a opcao c"},
+		{"letra isolada preservada", "2a via p boleto", "2a via p boleto"},
+		{"letras soltas não são abreviações", "n sei q fazer", "n sei q fazer"},
+		{"barra dentro de palavra", "top/c", "top c"},
+		{"vazio", "   ?! ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.in); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeIdempotent(t *testing.T) {
+	inputs := []string{
+		"Perdi meu cartão!!",
+		"vc sabe oq aconteceu c/ minha fatura?",
+		"pro meu cel",
+	}
+
+	for _, in := range inputs {
+		once := Normalize(in)
+		if twice := Normalize(once); twice != once {
+			t.Errorf("Normalize não é idempotente para %q: %q != %q", in, twice, once)
+		}
+	}
+}
+
+func TestFoldAccents(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"ação à vista, você é ótimo", "acao a vista, voce e otimo"},
+		{"ac\u0327a\u0303o a\u0300 vista", "acao a vista"},
+		{"pingüim, ñ", "pinguim, n"},
+	}
+	for _, tt := range tests {
+		if got := FoldAccents(tt.in); got != tt.want {
+			t.Errorf("FoldAccents(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"herois-da-pilha/util"
+)
+
+// Erros de ValidateBatch.
+var (
+	ErrBatchTooLarge = errors.New("lote grande demais")
+	ErrInvalidBatch  = errors.New("lote inválido")
+)
+
+// ValidateBatch exige entre 1 e maxSize itens, com IDs preenchidos e únicos
+// para que o cliente consiga associar cada resultado ao seu item.
+func ValidateBatch(items []util.BatchItem, maxSize int) error {
+	if len(items) == 0 {
+		return fmt.Errorf("%w: nenhum item", ErrInvalidBatch)
+	}
+	if len(items) > maxSize {
+		return fmt.Errorf("%w: %d itens excede o máximo de %d", ErrBatchTooLarge, len(items), maxSize)
+	}
+
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		if item.ID == "" {
+			return fmt.Errorf("%w: item %d sem id", ErrInvalidBatch, i)
+		}
+		if seen[item.ID] {
+			return fmt.Errorf("%w: id duplicado no lote: %q", ErrInvalidBatch, item.ID)
+		}
+		seen[item.ID] = true
+	}
+	return nil
+}
+
+// FindServiceBatch classifica os itens concorrentemente pelo mesmo caminho
+// de FindService (cache, coalescência de intenções idênticas e pool de
+// workers) e retorna os resultados na ordem dos itens. Itens sem intenção
+// falham com INVALID_BODY sem afetar os demais. No máximo numWorkers itens do
+// lote ficam em andamento ao mesmo tempo, para que um lote grande não
+// monopolize a fila de jobs.
+func (s *FinderService) FindServiceBatch(ctx context.Context, items []util.BatchItem, opts FindOptions) []util.BatchResult {
+	results := make([]util.BatchResult, len(items))
+	sem := make(chan struct{}, numWorkers)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		results[i].ID = item.ID
+		if item.Intent == "" {
+			results[i].FindServiceResponse = failure(util.ErrInvalidBody, "intenção vazia")
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].FindServiceResponse = s.FindService(ctx, item.Intent, opts)
+		}()
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"herois-da-pilha/util"
+)
+
+// Nomes das etapas da cascata.
+const (
+	StageExact = "exact"
+	StageFuzzy = "fuzzy"
+	StageLocal = "local"
+	StageLLM   = "llm"
+	// StageCache identifica respostas servidas pelo cache do FinderService.
+	StageCache = "cache"
+	// StageClarification identifica respostas resolvidas a partir da
+	// resposta do cliente a uma pergunta de esclarecimento.
+	StageClarification = "clarification"
+)
+
+// defaultStages é a ordem padrão: correspondência exata → fuzzy → modelo local → IA.
+const defaultStages = "exact,fuzzy,local,llm"
+
+// newCascadeFromEnv monta o CascadeClassifier a partir das variáveis de ambiente:
+//
+//	CASCADE_STAGES   ordem das etapas, sem repetição (default "exact,fuzzy,local,llm")
+//	FUZZY_MIN_SCORE  similaridade mínima da etapa fuzzy (default 0.9)
+//	LOCAL_MIN_SCORE  confiança mínima do classificador local (default 0.8)
+//	LLM_MIN_SCORE    confiança mínima da IA (default 0)
+//
+// As etapas locais usam os exemplos de res (veja loadResourcesFromEnv).
+func newCascadeFromEnv(res *Resources) (*CascadeClassifier, error) {
+	names := strings.Split(util.GetEnv("CASCADE_STAGES", defaultStages), ",")
+	examples := res.Examples
+
+	cascade := &CascadeClassifier{}
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		stage := Stage{Name: strings.TrimSpace(name)}
+		if stage.Name == "" {
+			continue
+		}
+		if seen[stage.Name] {
+			return nil, fmt.Errorf("etapa repetida em CASCADE_STAGES: %q", stage.Name)
+		}
+		seen[stage.Name] = true
+
+		switch stage.Name {
+		case StageExact:
+			stage.Classifier = NewExactClassifier(examples)
+			stage.MinScore = 1
+		case StageFuzzy:
+			stage.Classifier = NewFuzzyClassifier(examples)
+			stage.MinScore = util.GetEnvFloat("FUZZY_MIN_SCORE", 0.9)
+		case StageLocal:
+			stage.Classifier = NewLocalClassifier(examples)
+			stage.MinScore = util.GetEnvFloat("LOCAL_MIN_SCORE", 0.8)
+		case StageLLM:
+			stage.Classifier = newLLMClassifierFromEnv(res)
+			stage.MinScore = util.GetEnvFloat("LLM_MIN_SCORE", 0)
+		default:
+			return nil, fmt.Errorf("etapa inválida em CASCADE_STAGES: %q", stage.Name)
+		}
+		cascade.Stages = append(cascade.Stages, stage)
+	}
+
+	if len(cascade.Stages) == 0 {
+		return nil, fmt.Errorf("CASCADE_STAGES não define nenhuma etapa")
+	}
+
+	slog.Info("cascata de classificação configurada", "stages", strings.Join(names, ","))
+	return cascade, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// countingClassifier delega a inner e conta as chamadas.
+type countingClassifier struct {
+	inner Classifier
+	calls int
+}
+
+func (c *countingClassifier) Classify(ctx context.Context, intent string) (Prediction, error) {
+	c.calls++
+	return c.inner.Classify(ctx, intent)
+}
+
+func TestCascadeStageOrder(t *testing.T) {
+	exact := &countingClassifier{inner: fixedClassifier{err: ErrNoMatch}}
+	fuzzy := &countingClassifier{inner: scoredClassifier{score: 0.6}}
+	local := &countingClassifier{inner: scoredClassifier{score: 0.95}}
+	llm := &countingClassifier{inner: fixedClassifier{serviceID: 9}}
+	cascade := &CascadeClassifier{Stages: []Stage{
+		{Name: StageExact, Classifier: exact, MinScore: 1},
+		{Name: StageFuzzy, Classifier: fuzzy, MinScore: 0.9},
+		{Name: StageLocal, Classifier: local, MinScore: 0.8},
+		{Name: StageLLM, Classifier: llm},
+	}}
+
+	pred, err := cascade.Classify(context.Background(), "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// fuzzy ficou abaixo do MinScore; local respondeu e llm não foi consultada
+	if pred.Stage != StageLocal || pred.ServiceID != 3 || pred.Score != 0.95 {
+		t.Errorf("previsão = %+v, want serviço 3 pela etapa local", pred)
+	}
+	if got := []int{exact.calls, fuzzy.calls, local.calls, llm.calls}; !reflect.DeepEqual(got, []int{1, 1, 1, 0}) {
+		t.Errorf("chamadas por etapa = %v, want [1 1 1 0]", got)
+	}
+
+	// Sem MinScore atingido em nenhuma etapa, a cascata falha com os candidatos
+	cascade.Stages = cascade.Stages[:2]
+	pred, err = cascade.Classify(context.Background(), "x")
+	if !errors.Is(err, ErrNoMatch) || pred.Stage != "" || len(pred.Candidates) == 0 {
+		t.Errorf("sem resposta: %+v, %v; want ErrNoMatch com candidatos", pred, err)
+	}
+}
+
+func TestCascadeStopsOnFirstMatch(t *testing.T) {
+	exact := &countingClassifier{inner: fixedClassifier{serviceID: 5}}
+	llm := &countingClassifier{inner: fixedClassifier{serviceID: 9}}
+	cascade := &CascadeClassifier{Stages: []Stage{
+		{Name: StageExact, Classifier: exact, MinScore: 1},
+		{Name: StageLLM, Classifier: llm},
+	}}
+
+	pred, err := cascade.Classify(context.Background(), "x")
+	if err != nil || pred.Stage != StageExact || pred.ServiceID != 5 || llm.calls != 0 {
+		t.Errorf("previsão = %+v, %v (llm chamada %d vezes); want serviço 5 pela etapa exact", pred, err, llm.calls)
+	}
+}
+
+func TestCascadeStagesFromEnv(t *testing.T) {
+	res, err := LoadResources("", "", "", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		spec    string
+		want    []string
+		wantErr string
+	}{
+		{"", []string{StageExact, StageFuzzy, StageLocal, StageLLM}, ""},
+		{"local, exact", []string{StageLocal, StageExact}, ""},
+		{"fuzzy,,llm,", []string{StageFuzzy, StageLLM}, ""},
+		{"exact,semantic", nil, "etapa inválida"},
+		{"exact,local,exact", nil, "etapa repetida"},
+		{" , ", nil, "nenhuma etapa"},
+	}
+	for _, tt := range tests {
+		t.Setenv("CASCADE_STAGES", tt.spec)
+		t.Setenv("FUZZY_MIN_SCORE", "0.75")
+		cascade, err := newCascadeFromEnv(res)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%q: err = %v, want %q", tt.spec, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.spec, err)
+			continue
+		}
+		var names []string
+		for _, stage := range cascade.Stages {
+			names = append(names, stage.Name)
+			if stage.Name == StageFuzzy && stage.MinScore != 0.75 {
+				t.Errorf("%q: MinScore da etapa fuzzy = %v, want 0.75", tt.spec, stage.MinScore)
+			}
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("%q: etapas = %v, want %v", tt.spec, names, tt.want)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"herois-da-pilha/data"
+	"herois-da-pilha/normalize"
+	"herois-da-pilha/util"
+)
+
+// maxClarificationOptions é quantos serviços a pergunta oferece, no máximo.
+const maxClarificationOptions = 3
+
+// maxClarificationToken limita o tamanho do token aceito, antes de qualquer
+// decodificação (três IDs e a assinatura ocupam bem menos).
+const maxClarificationToken = 128
+
+// clarificationSigSize é o tamanho, em bytes, da assinatura HMAC do token.
+const clarificationSigSize = 16
+
+// clarificationKeyFromEnv retorna a chave de assinatura dos tokens de
+// esclarecimento, de CLARIFY_TOKEN_KEY. Sem ela, gera uma chave aleatória: os
+// tokens passam a valer só nesta instância e até o restart.
+func clarificationKeyFromEnv() []byte {
+	if key := util.GetEnv("CLARIFY_TOKEN_KEY", ""); key != "" {
+		return []byte(key)
+	}
+	slog.Info("CLARIFY_TOKEN_KEY não definida, tokens de esclarecimento valem só nesta instância")
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return key
+}
+
+// clarify troca uma resposta ambígua (sem correspondência, ou com confiança
+// abaixo de clarifyThreshold, inclusive quando roteada para o fallback) por
+// um pedido de esclarecimento com os serviços plausíveis, se houver pelo
+// menos dois candidatos com confiança mínima de clarifyMinConfidence.
+func (s *FinderService) clarify(response util.FindServiceResponse) util.FindServiceResponse {
+	ambiguous := response.ErrorCode == util.ErrNoMatch ||
+		(response.Success && response.Confidence < s.clarifyThreshold)
+	if !ambiguous {
+		return response
+	}
+
+	catalog := s.active.Load().res.Catalog
+	var options []util.ServiceCandidate
+	for _, c := range response.Candidates {
+		if c.Confidence < s.clarifyMinConfidence || c.ServiceID == catalog.FallbackID() {
+			continue
+		}
+		if options = append(options, c); len(options) == maxClarificationOptions {
+			break
+		}
+	}
+	if len(options) < 2 {
+		return response
+	}
+
+	response.Success = false
+	response.Data = util.ServiceData{}
+	response.Error = "intenção ambígua: confirme o serviço com o cliente"
+	response.ErrorCode = util.ErrNeedsClarification
+	response.Clarification = &util.Clarification{
+		Question: clarificationQuestion(catalog, options),
+		Options:  options,
+		Token:    encodeClarificationToken(s.clarifyKey, options),
+	}
+	return response
+}
+
+// clarificationQuestion monta a pergunta a ser falada pela URA, usando o
+// primeiro apelido de cada serviço (em geral mais curto que o nome).
+func clarificationQuestion(catalog *data.Catalog, options []util.ServiceCandidate) string {
+	labels := make([]string, len(options))
+	for i, o := range options {
+		labels[i] = o.ServiceName
+		if svc, ok := catalog.Lookup(o.ServiceID); ok && len(svc.Aliases) > 0 {
+			labels[i] = svc.Aliases[0]
+		}
+	}
+	last := len(labels) - 1
+	return fmt.Sprintf("Não entendi bem. Você quer %s ou %s?", strings.Join(labels[:last], ", "), labels[last])
+}
+
+// encodeClarificationToken codifica os IDs das opções, assinados com key
+// (HMAC-SHA256). O token não guarda estado no servidor, então a resposta pode
+// ser resolvida por qualquer instância com a mesma chave, mesmo após um
+// restart; a assinatura impede que o cliente escolha as opções.
+func encodeClarificationToken(key []byte, options []util.ServiceCandidate) string {
+	ids := make([]string, len(options))
+	for i, o := range options {
+		ids[i] = strconv.Itoa(o.ServiceID)
+	}
+	payload := []byte(strings.Join(ids, ","))
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(clarificationSig(key, payload))
+}
+
+// clarificationSig retorna a assinatura do conteúdo do token.
+func clarificationSig(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)[:clarificationSigSize]
+}
+
+// errInvalidClarificationToken é o erro de tokens malformados, adulterados ou
+// assinados com outra chave.
+var errInvalidClarificationToken = errors.New("clarification_token inválido")
+
+// decodeClarificationToken verifica a assinatura do token e retorna os IDs
+// das opções, validados no catálogo.
+func decodeClarificationToken(key []byte, catalog *data.Catalog, token string) ([]int, error) {
+	if len(token) > maxClarificationToken {
+		return nil, errInvalidClarificationToken
+	}
+	encodedPayload, encodedSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errInvalidClarificationToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, errInvalidClarificationToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil || !hmac.Equal(sig, clarificationSig(key, payload)) {
+		return nil, errInvalidClarificationToken
+	}
+
+	parts := strings.Split(string(payload), ",")
+	if len(parts) < 2 || len(parts) > maxClarificationOptions {
+		return nil, errInvalidClarificationToken
+	}
+	ids := make([]int, len(parts))
+	for i, p := range parts {
+		id, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, errInvalidClarificationToken
+		}
+		// Um token antigo pode citar um serviço removido por um reload
+		if _, ok := catalog.Lookup(id); !ok {
+			return nil, fmt.Errorf("clarification_token cita serviço fora do catálogo: %d", id)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// resolveClarification interpreta a resposta do cliente à pergunta de
+// esclarecimento, na ordem:
+//
+//  1. a resposta indica a posição da opção ("a segunda", "opção 1");
+//  2. a resposta classificada normalmente é uma das opções;
+//  3. entre os candidatos da resposta, uma das opções se destaca das demais;
+//  4. caso contrário, vale a classificação da resposta (o cliente mudou de
+//     assunto), que pode pedir um novo esclarecimento.
+func (s *FinderService) resolveClarification(ctx context.Context, answer string, opts FindOptions) util.FindServiceResponse {
+	catalog := s.active.Load().res.Catalog
+	options, err := decodeClarificationToken(s.clarifyKey, catalog, opts.ClarificationToken)
+	if err != nil {
+		return failure(util.ErrInvalidBody, err.Error())
+	}
+
+	if i, ok := ordinalAnswer(answer, len(options)); ok {
+		if opts.SessionID != "" {
+			s.sessions.Append(opts.SessionID, normalize.Normalize(answer))
+		}
+		return s.resolved(catalog, options[i], 1, nil)
+	}
+
+	response := s.findService(ctx, answer, opts)
+	if response.Success && containsID(options, response.Data.ServiceID) {
+		return s.resolved(catalog, response.Data.ServiceID, response.Confidence, response.Candidates)
+	}
+	if best, ok := s.leadingOption(options, response.Candidates); ok {
+		return s.resolved(catalog, best.ServiceID, best.Confidence, response.Candidates)
+	}
+
+	if opts.Clarify {
+		response = s.clarify(response)
+	}
+	return response
+}
+
+// leadingOption retorna a opção mais provável entre os candidatos, desde que
+// atinja clarifyMinConfidence e tenha pelo menos o dobro da confiança da
+// segunda opção mais provável; empates continuam ambíguos.
+func (s *FinderService) leadingOption(options []int, candidates []util.ServiceCandidate) (util.ServiceCandidate, bool) {
+	var best, runnerUp util.ServiceCandidate
+	for _, c := range candidates {
+		if !containsID(options, c.ServiceID) {
+			continue
+		}
+		switch {
+		case c.Confidence > best.Confidence:
+			best, runnerUp = c, best
+		case c.Confidence > runnerUp.Confidence:
+			runnerUp = c
+		}
+	}
+	ok := best.Confidence >= s.clarifyMinConfidence && best.Confidence >= 2*runnerUp.Confidence
+	return best, ok
+}
+
+// resolved monta a resposta de um esclarecimento resolvido.
+func (s *FinderService) resolved(catalog *data.Catalog, serviceID int, confidence float64, candidates []util.ServiceCandidate) util.FindServiceResponse {
+	name, _ := catalog.Name(serviceID)
+	s.stats.record(StageClarification)
+	return util.FindServiceResponse{
+		Success:    true,
+		Data:       util.ServiceData{ServiceID: serviceID, ServiceName: name},
+		Confidence: confidence,
+		Candidates: candidates,
+		Stage:      StageClarification,
+	}
+}
+
+// ordinals mapeia as palavras de posição (normalizadas) para o índice da
+// opção; -1 é a última.
+var ordinals = map[string]int{
+	"1": 0, "um": 0, "primeiro": 0, "primeira": 0,
+	"2": 1, "dois": 1, "segundo": 1, "segunda": 1,
+	"3": 2, "tres": 2, "terceiro": 2, "terceira": 2,
+	"ultimo": -1, "ultima": -1,
+}
+
+// ordinalFillers são as palavras aceitas ao redor da posição ("quero a
+// segunda", "opcao 1").
+var ordinalFillers = map[string]bool{
+	"a": true, "o": true, "e": true, "quero": true, "opcao": true,
+	"numero": true, "essa": true, "esse": true,
+}
+
+// ordinalAnswer reconhece respostas que apenas indicam a posição da opção.
+// Qualquer outra palavra desqualifica a resposta, para que "segunda via" não
+// vire a opção 2.
+func ordinalAnswer(answer string, n int) (int, bool) {
+	index, found := 0, false
+	for _, w := range strings.Fields(normalize.Normalize(answer)) {
+		i, ok := ordinals[w]
+		switch {
+		case ok && !found:
+			index, found = i, true
+		case !ordinalFillers[w]:
+			return 0, false
+		}
+	}
+	if index < 0 {
+		index = n - 1
+	}
+	return index, found && index < n
+}
+
+func containsID(ids []int, id int) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"herois-da-pilha/util"
+)
+
+func options(ids ...int) []util.ServiceCandidate {
+	opts := make([]util.ServiceCandidate, len(ids))
+	for i, id := range ids {
+		opts[i] = util.ServiceCandidate{ServiceID: id}
+	}
+	return opts
+}
+
+// signedToken assina um conteúdo arbitrário, como um token bem formado.
+func signedToken(key []byte, payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(clarificationSig(key, []byte(payload)))
+}
+
+func TestDecodeClarificationToken(t *testing.T) {
+	res, err := LoadResources("", "", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("chave")
+
+	token := encodeClarificationToken(key, options(3, 2, 13))
+	ids, err := decodeClarificationToken(key, res.Catalog, token)
+	if err != nil || len(ids) != 3 || ids[0] != 3 || ids[1] != 2 || ids[2] != 13 {
+		t.Fatalf("decode = %v, %v; want [3 2 13]", ids, err)
+	}
+
+	_, sig, _ := strings.Cut(token, ".")
+	tests := []struct {
+		name, token, wantErr string
+	}{
+		{"outra chave", encodeClarificationToken([]byte("outra"), options(3, 2)), "inválido"},
+		{"opções trocadas", base64.RawURLEncoding.EncodeToString([]byte("15,2")) + "." + sig, "inválido"},
+		{"sem assinatura", base64.RawURLEncoding.EncodeToString([]byte("3,2")), "inválido"},
+		{"base64 inválido", "***." + sig, "inválido"},
+		{"longo demais", signedToken(key, strings.Repeat("1,", maxClarificationToken)+"1"), "inválido"},
+		{"uma opção", signedToken(key, "3"), "inválido"},
+		{"opções demais", signedToken(key, "1,2,3,4"), "inválido"},
+		{"ID não numérico", signedToken(key, "3,x"), "inválido"},
+		{"fora do catálogo", signedToken(key, "3,99"), "fora do catálogo"},
+	}
+	for _, tt := range tests {
+		if _, err := decodeClarificationToken(key, res.Catalog, tt.token); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOrdinalAnswer(t *testing.T) {
+	tests := []struct {
+		answer    string
+		n         int
+		wantIndex int
+		wantOK    bool
+	}{
+		{"a segunda", 3, 1, true},
+		{"opção 1", 3, 0, true},
+		{"Quero a TERCEIRA", 3, 2, true},
+		{"a última", 3, 2, true},
+		{"a ultima", 2, 1, true},
+		{"dois", 2, 1, true},
+		{"a terceira", 2, 0, false},
+		{"segunda via", 3, 0, false},
+		{"1 2", 3, 0, false},
+		{"quero", 3, 0, false},
+		{"", 3, 0, false},
+	}
+	for _, tt := range tests {
+		index, ok := ordinalAnswer(tt.answer, tt.n)
+		if ok != tt.wantOK || (ok && index != tt.wantIndex) {
+			t.Errorf("ordinalAnswer(%q, %d) = %d, %v; want %d, %v", tt.answer, tt.n, index, ok, tt.wantIndex, tt.wantOK)
+		}
+	}
+}
+
+func TestLeadingOption(t *testing.T) {
+	s := newTestFinder(t, &recordingClassifier{})
+	s.clarifyMinConfidence = 0.1
+
+	cand := func(id int, confidence float64) util.ServiceCandidate {
+		return util.ServiceCandidate{ServiceID: id, Confidence: confidence}
+	}
+	tests := []struct {
+		name       string
+		candidates []util.ServiceCandidate
+		wantID     int
+		wantOK     bool
+	}{
+		// Candidatos fora das opções não contam, mesmo que mais prováveis
+		{"destacada", []util.ServiceCandidate{cand(13, 0.9), cand(3, 0.6), cand(2, 0.2)}, 3, true},
+		{"exatamente o dobro", []util.ServiceCandidate{cand(2, 0.4), cand(3, 0.2)}, 2, true},
+		{"empate técnico", []util.ServiceCandidate{cand(3, 0.4), cand(2, 0.3)}, 0, false},
+		{"abaixo do mínimo", []util.ServiceCandidate{cand(3, 0.05)}, 0, false},
+		{"sem opções", []util.ServiceCandidate{cand(13, 0.9)}, 0, false},
+	}
+	for _, tt := range tests {
+		best, ok := s.leadingOption([]int{3, 2}, tt.candidates)
+		if ok != tt.wantOK || (ok && best.ServiceID != tt.wantID) {
+			t.Errorf("%s: leadingOption = %d, %v; want %d, %v", tt.name, best.ServiceID, ok, tt.wantID, tt.wantOK)
+		}
+	}
+}
+
+// answerClassifier responde sempre a mesma previsão.
+type answerClassifier struct {
+	prediction Prediction
+	err        error
+}
+
+func (c answerClassifier) Classify(context.Context, string) (Prediction, error) {
+	return c.prediction, c.err
+}
+
+func TestResolveClarification(t *testing.T) {
+	tests := []struct {
+		name       string
+		answer     string
+		classifier answerClassifier
+		wantID     int
+		wantStage  string
+	}{
+		{"posição", "a segunda", answerClassifier{err: ErrNoMatch}, 7, StageClarification},
+		{"classificação é uma opção", "o desbloqueio", answerClassifier{prediction: Prediction{ServiceID: 9, Score: 0.9}}, 9, StageClarification},
+		{"opção destacada", "hm cartao", answerClassifier{
+			prediction: Prediction{Candidates: []Candidate{{ServiceID: 7, Confidence: 0.5}, {ServiceID: 8, Confidence: 0.1}}},
+			err:        ErrNoMatch,
+		}, 7, StageClarification},
+		{"mudou de assunto", "quero pagar a fatura", answerClassifier{prediction: Prediction{ServiceID: 13, Score: 0.9, Stage: StageLocal}}, 13, StageLocal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestFinder(t, tt.classifier)
+			token := encodeClarificationToken(s.clarifyKey, options(8, 7, 9))
+
+			resp := s.resolveClarification(context.Background(), tt.answer, FindOptions{ClarificationToken: token})
+			if !resp.Success || resp.Data.ServiceID != tt.wantID || resp.Stage != tt.wantStage {
+				t.Errorf("resposta = %+v, want serviço %d na etapa %q", resp, tt.wantID, tt.wantStage)
+			}
+		})
+	}
+
+	// Token de outra instância (chave diferente) é rejeitado
+	s := newTestFinder(t, answerClassifier{err: ErrNoMatch})
+	token := encodeClarificationToken([]byte("outra"), options(8, 7, 9))
+	if resp := s.resolveClarification(context.Background(), "a segunda", FindOptions{ClarificationToken: token}); resp.ErrorCode != util.ErrInvalidBody {
+		t.Errorf("token de outra chave: %+v, want INVALID_BODY", resp)
+	}
+}
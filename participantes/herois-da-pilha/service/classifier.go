@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"herois-da-pilha/tracing"
+	"herois-da-pilha/util"
+)
+
+// ErrNoMatch indica que o classificador não encontrou correspondência clara para a intenção.
+var ErrNoMatch = errors.New("nenhuma correspondência clara encontrada para a intenção")
+
+// Candidate é um serviço candidato com a confiança calibrada (0 a 1).
+type Candidate struct {
+	ServiceID  int
+	Confidence float64
+}
+
+// Prediction é o resultado de uma classificação.
+type Prediction struct {
+	ServiceID int
+	// Score é a confiança calibrada do classificador na previsão (0 a 1).
+	Score float64
+	// Candidates são os serviços considerados, em ordem decrescente de confiança.
+	// O primeiro corresponde a ServiceID.
+	Candidates []Candidate
+	// Stage é o nome da etapa da cascata que produziu a previsão.
+	Stage string
+}
+
+// Classifier classifica uma intenção em um dos serviços válidos.
+type Classifier interface {
+	Classify(ctx context.Context, intent string) (Prediction, error)
+}
+
+// Stage é uma etapa do CascadeClassifier.
+type Stage struct {
+	Name       string
+	Classifier Classifier
+	// MinScore é a confiança mínima para a etapa responder; abaixo dela a
+	// próxima etapa é consultada.
+	MinScore float64
+}
+
+// CascadeClassifier consulta as etapas em ordem (da mais barata para a mais
+// cara) e responde com a primeira cuja confiança atinja o MinScore da etapa.
+// A previsão informa em Stage qual etapa respondeu. Se nenhuma etapa
+// responder, a previsão retornada com o erro traz apenas os candidatos
+// reunidos pelas etapas.
+type CascadeClassifier struct {
+	Stages []Stage
+}
+
+// Classify implementa Classifier.
+func (c *CascadeClassifier) Classify(ctx context.Context, intent string) (Prediction, error) {
+	var (
+		candidates []Candidate
+		lastErr    error = ErrNoMatch
+	)
+
+	for _, stage := range c.Stages {
+		start := time.Now()
+		stageCtx, span := tracing.Start(ctx, "classifier."+stage.Name, tracing.KindInternal)
+		pred, err := stage.Classifier.Classify(stageCtx, intent)
+		util.RequestLogFrom(ctx).AddStage(stage.Name, time.Since(start))
+		if err == nil {
+			span.Set("ivr.service_id", pred.ServiceID)
+			span.Set("ivr.score", pred.Score)
+		} else if !errors.Is(err, ErrNoMatch) {
+			span.SetError(err)
+		}
+		span.End()
+		if err != nil {
+			// Sem correspondência, a etapa ainda pode indicar os serviços
+			// plausíveis (veja FinderService.clarify)
+			if errors.Is(err, ErrNoMatch) && len(pred.Candidates) > 0 {
+				candidates = mergeCandidates(pred.Candidates, candidates)
+			}
+			lastErr = err
+			continue
+		}
+
+		// Candidatos de etapas anteriores complementam os da etapa atual
+		pred.Candidates = mergeCandidates(pred.Candidates, candidates)
+		if pred.Score >= stage.MinScore {
+			pred.Stage = stage.Name
+			return pred, nil
+		}
+		candidates = pred.Candidates
+		lastErr = ErrNoMatch
+	}
+
+	// Os candidatos acumulados acompanham a falha
+	return Prediction{Candidates: candidates}, lastErr
+}
+
+// mergeCandidates completa os candidatos de maior precedência com os demais,
+// redistribuindo a massa de probabilidade restante entre eles. O primeiro
+// candidato de primary (a previsão escolhida) permanece na frente.
+func mergeCandidates(primary, secondary []Candidate) []Candidate {
+	seen := make(map[int]bool, len(primary))
+	var mass float64
+	for _, c := range primary {
+		seen[c.ServiceID] = true
+		mass += c.Confidence
+	}
+
+	remaining := 1 - mass
+	if remaining <= 0 {
+		return primary
+	}
+
+	var secondaryMass float64
+	for _, c := range secondary {
+		if !seen[c.ServiceID] {
+			secondaryMass += c.Confidence
+		}
+	}
+	if secondaryMass == 0 {
+		return primary
+	}
+
+	merged := append([]Candidate(nil), primary...)
+	for _, c := range secondary {
+		if seen[c.ServiceID] {
+			continue
+		}
+		merged = append(merged, Candidate{
+			ServiceID:  c.ServiceID,
+			Confidence: c.Confidence / secondaryMass * remaining,
+		})
+	}
+	if len(primary) > 0 {
+		sortCandidates(merged[1:])
+	}
+	return merged
+}
+
+func sortCandidates(candidates []Candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+}
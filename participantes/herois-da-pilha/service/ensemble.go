@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"herois-da-pilha/llm"
+	"herois-da-pilha/tracing"
+	"herois-da-pilha/util"
+)
+
+// StageEnsemble identifica respostas produzidas pelo EnsembleClassifier.
+const StageEnsemble = "ensemble"
+
+// Modos de ensemble da implantação (ENSEMBLE_MODE).
+const (
+	// EnsembleOff desativa o ensemble, mesmo se solicitado.
+	EnsembleOff = "off"
+	// EnsembleOnRequest usa o ensemble apenas quando a requisição pede.
+	EnsembleOnRequest = "request"
+	// EnsembleAlways usa o ensemble por padrão; a requisição pode desativar.
+	EnsembleAlways = "always"
+)
+
+// EnsembleMember é um classificador participante da votação.
+type EnsembleMember struct {
+	Name       string
+	Classifier Classifier
+	Weight     float64
+}
+
+// EnsembleClassifier consulta todos os membros em paralelo e escolhe o
+// serviço por maioria ponderada. A confiança é o nível de concordância: o
+// peso dos votos no vencedor dividido pelo peso de todos os membros.
+// Membros que falham ou não encontram correspondência não votam, mas contam
+// como abstenção: um vencedor isolado porque os demais falharam não tem
+// confiança total.
+type EnsembleClassifier struct {
+	Members []EnsembleMember
+}
+
+// Classify implementa Classifier.
+func (e *EnsembleClassifier) Classify(ctx context.Context, intent string) (Prediction, error) {
+	type vote struct {
+		prediction Prediction
+		err        error
+	}
+
+	votes := make([]vote, len(e.Members))
+	var wg sync.WaitGroup
+	for i, m := range e.Members {
+		wg.Add(1)
+		go func(i int, m EnsembleMember) {
+			defer wg.Done()
+			start := time.Now()
+			memberCtx, span := tracing.Start(ctx, "classifier."+StageEnsemble+":"+m.Name, tracing.KindInternal)
+			p, err := m.Classifier.Classify(memberCtx, intent)
+			util.RequestLogFrom(ctx).AddStage(StageEnsemble+":"+m.Name, time.Since(start))
+			if err == nil {
+				span.Set("ivr.service_id", p.ServiceID)
+			} else if !errors.Is(err, ErrNoMatch) {
+				span.SetError(err)
+			}
+			span.End()
+			votes[i] = vote{prediction: p, err: err}
+		}(i, m)
+	}
+	wg.Wait()
+
+	tally := make(map[int]float64)
+	var voted, total float64
+	var lastErr error = ErrNoMatch
+	for i, v := range votes {
+		total += e.Members[i].Weight
+		if v.err != nil {
+			lastErr = v.err
+			continue
+		}
+		tally[v.prediction.ServiceID] += e.Members[i].Weight
+		voted += e.Members[i].Weight
+	}
+	if voted == 0 {
+		return Prediction{}, lastErr
+	}
+
+	candidates := make([]Candidate, 0, len(tally))
+	for id, weight := range tally {
+		candidates = append(candidates, Candidate{ServiceID: id, Confidence: weight / total})
+	}
+	// Empates são decididos pelo menor ID, para o resultado ser determinístico
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].ServiceID < candidates[j].ServiceID
+	})
+
+	return Prediction{
+		ServiceID:  candidates[0].ServiceID,
+		Score:      candidates[0].Confidence,
+		Candidates: candidates,
+		Stage:      StageEnsemble,
+	}, nil
+}
+
+// newEnsembleFromEnv monta o EnsembleClassifier a partir de ENSEMBLE_MEMBERS,
+// uma lista "membro=peso" separada por vírgulas, em que membro é "local",
+// "fuzzy" ou um modelo de IA (ex: "local=1,openai/gpt-4o-mini=2"). Cada
+// modelo usa os provedores de llm.ConfigFromEnv, sem hedge.
+func newEnsembleFromEnv(res *Resources) (*EnsembleClassifier, error) {
+	spec := util.GetEnv("ENSEMBLE_MEMBERS", "local=1,openai/gpt-4o-mini=2,google/gemini-2.0-flash-001=1")
+
+	e := &EnsembleClassifier{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, weight := item, 1.0
+		if i := strings.LastIndex(item, "="); i >= 0 {
+			w, err := strconv.ParseFloat(item[i+1:], 64)
+			if err != nil || w <= 0 {
+				return nil, fmt.Errorf("peso inválido em ENSEMBLE_MEMBERS: %q", item)
+			}
+			name, weight = item[:i], w
+		}
+
+		member := EnsembleMember{Name: name, Weight: weight}
+		switch name {
+		case StageLocal:
+			member.Classifier = NewLocalClassifier(res.Examples)
+		case StageFuzzy:
+			member.Classifier = NewFuzzyClassifier(res.Examples)
+		default:
+			member.Classifier = newModelClassifier(name, res)
+		}
+		e.Members = append(e.Members, member)
+	}
+
+	if len(e.Members) == 0 {
+		return nil, fmt.Errorf("ENSEMBLE_MEMBERS não define nenhum membro")
+	}
+
+	slog.Info("ensemble configurado", "members", len(e.Members), "spec", spec)
+	return e, nil
+}
+
+// newModelClassifier cria um classificador de IA para um único modelo.
+func newModelClassifier(model string, res *Resources) *LLMClassifier {
+	cfg, err := llm.ConfigFromEnv()
+	if err != nil {
+		cfg = llm.Config{}
+	}
+	cfg.Providers = []llm.ProviderConfig{{Name: "ensemble:" + model, Model: model}}
+	cfg.Hedge.Enabled = false
+
+	classifier := NewLLMClassifier(nil, res.Catalog, res.Prompts)
+	classifier.provider = cfg.Build(classifier.acceptResponse)
+	return classifier
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fixedClassifier responde sempre com o mesmo serviço ou erro.
+type fixedClassifier struct {
+	serviceID int
+	err       error
+}
+
+func (c fixedClassifier) Classify(context.Context, string) (Prediction, error) {
+	if c.err != nil {
+		return Prediction{}, c.err
+	}
+	return Prediction{ServiceID: c.serviceID, Score: 1}, nil
+}
+
+func TestEnsembleConfidence(t *testing.T) {
+	timeout := fixedClassifier{err: context.DeadlineExceeded}
+	tests := []struct {
+		name    string
+		members []EnsembleMember
+		want    float64
+	}{
+		{"unânime", []EnsembleMember{
+			{Name: "a", Classifier: fixedClassifier{serviceID: 3}, Weight: 1},
+			{Name: "b", Classifier: fixedClassifier{serviceID: 3}, Weight: 2},
+		}, 1},
+		{"dividido", []EnsembleMember{
+			{Name: "a", Classifier: fixedClassifier{serviceID: 3}, Weight: 3},
+			{Name: "b", Classifier: fixedClassifier{serviceID: 5}, Weight: 1},
+		}, 0.75},
+		// As abstenções reduzem a concordância
+		{"demais falharam", []EnsembleMember{
+			{Name: "local", Classifier: fixedClassifier{serviceID: 3}, Weight: 1},
+			{Name: "llm1", Classifier: timeout, Weight: 2},
+			{Name: "llm2", Classifier: timeout, Weight: 1},
+		}, 0.25},
+	}
+	for _, tt := range tests {
+		pred, err := (&EnsembleClassifier{Members: tt.members}).Classify(context.Background(), "x")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if pred.ServiceID != 3 || pred.Score != tt.want {
+			t.Errorf("%s: serviço %d, confiança %v; want 3, %v", tt.name, pred.ServiceID, pred.Score, tt.want)
+		}
+	}
+}
+
+func TestEnsembleAllFail(t *testing.T) {
+	e := &EnsembleClassifier{Members: []EnsembleMember{
+		{Name: "a", Classifier: fixedClassifier{err: ErrNoMatch}, Weight: 1},
+		{Name: "b", Classifier: fixedClassifier{err: ErrNoMatch}, Weight: 1},
+	}}
+	if _, err := e.Classify(context.Background(), "x"); !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("err = %v, want ErrNoMatch", err)
+	}
+}
@@ -2,56 +2,174 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"strconv"
+	"log/slog"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"herois-da-pilha/cache"
 	"herois-da-pilha/data"
+	"herois-da-pilha/llm"
+	"herois-da-pilha/normalize"
+	"herois-da-pilha/tracing"
 	"herois-da-pilha/util"
-
-	"github.com/sashabaranov/go-openai"
 )
 
-// FinderService é o struct que gerencia a lógica de IA e o cache.
+// numWorkers é o tamanho do pool de workers de classificação.
+const numWorkers = 10 // Revertido para 10 workers
+
+// FinderService é o struct que gerencia a lógica de classificação e o cache.
 type FinderService struct {
-	openAIClient *openai.Client
-	modelName    string
-	cache        map[string]util.FindServiceResponse // Adicionado cache
-	mu           sync.RWMutex                        // Mutex para proteger o acesso ao cache
-	jobChannel   chan util.JobRequest
-	wg           sync.WaitGroup
+	// active são os classificadores da configuração em uso, trocados
+	// atomicamente por Reload.
+	active atomic.Pointer[classifierSet]
+	// reloadMu serializa os reloads; swapMu impede que um worker grave no
+	// cache uma resposta da configuração anterior durante a troca.
+	reloadMu sync.Mutex
+	swapMu   sync.RWMutex
+	// ensembleMode define quando o ensemble é usado no lugar da cascata.
+	ensembleMode string
+	// minConfidence é o limite abaixo do qual a intenção é roteada para o
+	// serviço de fallback do catálogo em vez de falhar. Zero desativa o roteamento.
+	minConfidence float64
+	cache         cache.Cache[util.FindServiceResponse]
+	cacheTTL      time.Duration // TTL das respostas de sucesso (0 = sem expiração)
+	negativeTTL   time.Duration // TTL das respostas de falha (0 = não armazena)
+	jobChannel    chan util.JobRequest
+	wg            sync.WaitGroup
+	stats         stageStats
+	flights       flightGroup
+	coalesced     atomic.Uint64
+	snapshotPath  string      // arquivo de persistência do cache ("" = desativado)
+	ready         atomic.Bool // false enquanto o pre-warm está em andamento
+	liveWorkers   atomic.Int32
+	busyWorkers   atomic.Int32 // workers classificando no momento
+	queued        atomic.Int64 // jobs aguardando um worker livre
+	probe         upstreamProbe
+	sessions      *SessionStore
+	// clarifyThreshold e clarifyMinConfidence controlam os pedidos de
+	// esclarecimento (veja clarify); clarifyKey assina os tokens.
+	clarifyThreshold     float64
+	clarifyMinConfidence float64
+	clarifyKey           []byte
+
+	// Controle de encerramento (veja Close)
+	closeMu    sync.RWMutex
+	closed     bool
+	pending    sync.WaitGroup     // requisições aguardando resposta de um worker
+	workCtx    context.Context    // contexto base das classificações
+	cancelWork context.CancelFunc // aborta as classificações em andamento
+	done       chan struct{}      // encerra as goroutines de manutenção
 }
 
-// NewFinderService inicializa o cliente OpenAI (OpenRouter) e o cache.
+// ErrShuttingDown indica que o serviço está encerrando e não aceita novas classificações.
+var ErrShuttingDown = errors.New("serviço em encerramento, tente novamente")
+
+// NewFinderService carrega o catálogo e os dados de classificação (veja
+// loadResourcesFromEnv) e inicializa a cascata de classificadores (veja
+// newCascadeFromEnv) e o cache. HUMAN_FALLBACK_THRESHOLD define a confiança
+// mínima abaixo da qual a intenção vai para o serviço de fallback do
+// catálogo ("Atendimento humano").
+//
+// O cache é um LRU limitado, configurado por:
+//
+//	CACHE_MAX_ENTRIES  número máximo de entradas (default 10000)
+//	CACHE_MAX_BYTES    tamanho estimado máximo em bytes (default 16MB)
+//	CACHE_TTL          validade das respostas de sucesso (default 24h)
+//	CACHE_NEGATIVE_TTL validade das respostas de falha (default 10s, 0 desativa)
+//
+// Persistência e aquecimento do cache:
+//
+//	CACHE_SNAPSHOT_PATH     arquivo do snapshot, carregado na inicialização ("" desativa)
+//	CACHE_SNAPSHOT_INTERVAL intervalo entre gravações periódicas (default 1m)
+//	PREWARM                 classifica todo o dataset na inicialização (default false)
+//
+// ENSEMBLE_MODE ("off", "request" ou "always", default "request") controla o
+// modo ensemble (veja newEnsembleFromEnv).
+//
+// Sessões de ligação (veja SessionStore):
+//
+//	SESSION_MAX_SESSIONS número máximo de sessões guardadas (default 10000)
+//	SESSION_TTL          expiração de uma sessão sem novos turnos (default 10m)
+//	SESSION_MAX_TURNS    turnos anteriores usados como contexto (default 3)
+//
+// Esclarecimento de intenções ambíguas (veja FindOptions.Clarify):
+//
+//	CLARIFY_THRESHOLD      confiança abaixo da qual o serviço é confirmado com o cliente (default 0.6)
+//	CLARIFY_MIN_CONFIDENCE confiança mínima de um serviço para ser oferecido (default 0.1)
 func NewFinderService() *FinderService {
-	apiKey := os.Getenv("OPENROUTER_API_KEY")
-	if apiKey == "" {
-		fmt.Println("AVISO: Variável OPENROUTER_API_KEY não está definida!")
+	res, err := loadResourcesFromEnv()
+	if err != nil {
+		slog.Warn("configuração inválida, usando catálogo, dataset e prompt embutidos", "error", err)
+		if res, err = LoadResources("", "", "", util.GetEnvInt("FEWSHOT_EXAMPLES", 12)); err != nil {
+			panic(err)
+		}
 	}
 
-	config := openai.DefaultConfig(apiKey)
-	config.BaseURL = "https://openrouter.ai/api/v1"
+	ensembleMode := util.GetEnv("ENSEMBLE_MODE", EnsembleOnRequest)
+	responseCache := cache.NewLRU(cache.Options[util.FindServiceResponse]{
+		MaxEntries: util.GetEnvInt("CACHE_MAX_ENTRIES", 10000),
+		MaxBytes:   int64(util.GetEnvInt("CACHE_MAX_BYTES", 16<<20)),
+		SizeOf:     responseSize,
+	})
+
+	s := newFinderService(newClassifierSet(res, ensembleMode), responseCache)
+	s.ensembleMode = ensembleMode
+	s.minConfidence = util.GetEnvFloat("HUMAN_FALLBACK_THRESHOLD", 0)
+	if s.minConfidence > 0 && res.Catalog.FallbackID() == 0 {
+		slog.Warn("o catálogo não define serviço de fallback; HUMAN_FALLBACK_THRESHOLD ignorado")
+		s.minConfidence = 0
+	}
+	s.cacheTTL = util.GetEnvDuration("CACHE_TTL", 24*time.Hour)
+	s.negativeTTL = util.GetEnvDuration("CACHE_NEGATIVE_TTL", 10*time.Second)
 
-	// Modelo recomendado para performance e custo
-	model := "openai/gpt-4o-mini" // Revertido para GPT-4o Mini para corrigir a acurácia
+	s.snapshotPath = util.GetEnv("CACHE_SNAPSHOT_PATH", "")
+	if err := s.LoadSnapshot(); err != nil {
+		slog.Warn("snapshot do cache não carregado", "error", err)
+	}
+	if s.snapshotPath != "" {
+		s.startSnapshotLoop(util.GetEnvDuration("CACHE_SNAPSHOT_INTERVAL", time.Minute))
+	}
 
-	fmt.Printf("Serviço Finder inicializado com: \n")
-	fmt.Printf("  Modelo de IA: %s\n", model)
-	fmt.Printf("  URL Base da API: %s\n", config.BaseURL)
+	if util.GetEnvBool("PREWARM", false) {
+		s.ready.Store(false)
+		go s.Prewarm(res.Examples, numWorkers)
+	}
+	return s
+}
+
+// NewFinderServiceWith inicializa o serviço com os recursos, o classificador e
+// o cache informados.
+func NewFinderServiceWith(res *Resources, classifier Classifier, responseCache cache.Cache[util.FindServiceResponse]) *FinderService {
+	return newFinderService(&classifierSet{
+		res:        res,
+		classifier: classifier,
+		llm:        findLLMClassifier(classifier),
+	}, responseCache)
+}
 
+func newFinderService(set *classifierSet, responseCache cache.Cache[util.FindServiceResponse]) *FinderService {
 	s := &FinderService{
-		openAIClient: openai.NewClientWithConfig(config),
-		modelName:    model,
-		cache:        make(map[string]util.FindServiceResponse), // Inicializa o cache
-		mu:           sync.RWMutex{},                            // Inicializa o mutex
-		jobChannel:   make(chan util.JobRequest),
+		cache:      responseCache,
+		jobChannel: make(chan util.JobRequest),
+		done:       make(chan struct{}),
+		sessions: NewSessionStore(
+			util.GetEnvInt("SESSION_MAX_SESSIONS", 10000),
+			util.GetEnvDuration("SESSION_TTL", 10*time.Minute),
+			util.GetEnvInt("SESSION_MAX_TURNS", 3),
+		),
+		clarifyThreshold:     util.GetEnvFloat("CLARIFY_THRESHOLD", 0.6),
+		clarifyMinConfidence: util.GetEnvFloat("CLARIFY_MIN_CONFIDENCE", 0.1),
+		clarifyKey:           clarificationKeyFromEnv(),
+		probe:                upstreamProbe{ttl: util.GetEnvDuration("READYZ_PROBE_TTL", 30*time.Second)},
 	}
+	s.active.Store(set)
+	s.workCtx, s.cancelWork = context.WithCancel(context.Background())
 
-	numWorkers := 10 // Revertido para 10 workers
+	s.ready.Store(true)
 
 	for i := 0; i < numWorkers; i++ {
 		s.wg.Add(1)
@@ -61,131 +179,459 @@ func NewFinderService() *FinderService {
 	return s
 }
 
-func getPrompt() string {
-	return data.IntentClassificationPrompt
+// newLLMClassifierFromEnv cria o classificador de IA com os provedores
+// configurados (veja llm.ConfigFromEnv), com failover entre eles.
+func newLLMClassifierFromEnv(res *Resources) *LLMClassifier {
+	cfg, err := llm.ConfigFromEnv()
+	if err != nil {
+		slog.Warn("configuração de IA inválida, usando o modelo padrão", "error", err)
+		cfg = llm.Config{Providers: []llm.ProviderConfig{{Model: "openai/gpt-4o-mini"}}}
+	}
+
+	classifier := NewLLMClassifier(nil, res.Catalog, res.Prompts)
+	classifier.provider = cfg.Build(classifier.acceptResponse)
+	return classifier
 }
 
 func (s *FinderService) worker() {
 	defer s.wg.Done()
+	s.liveWorkers.Add(1)
+	defer s.liveWorkers.Add(-1)
+
 	for job := range s.jobChannel {
-		func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			defer cancel()
+		// O solicitante já desistiu enquanto o job estava na fila
+		if job.Ctx.Err() != nil {
+			continue
+		}
+
+		s.busyWorkers.Add(1)
+		set := s.active.Load()
+		classifier := set.classifier
+		if job.Ensemble && set.ensemble != nil {
+			classifier = set.ensemble
+		}
+		ctx, span := tracing.Start(job.Ctx, "classify", tracing.KindInternal)
+		if len(job.History) > 0 {
+			span.Set("ivr.history_turns", len(job.History))
+		}
+		response := s.classify(ctx, set, classifier, job.Intent, job.History)
+		span.Set("ivr.success", response.Success)
+		span.Set("ivr.service_id", response.Data.ServiceID)
+		span.Set("ivr.stage", response.Stage)
+		span.End()
+
+		// Armazenar no cache. Falhas ficam apenas pelo TTL negativo, para que
+		// erros transitórios não se perpetuem; falhas causadas pelo
+		// cancelamento do solicitante ou pelo aborto das classificações no
+		// encerramento não são armazenadas. Respostas de uma configuração
+		// substituída durante a classificação são descartadas.
+		key := cacheKey(job.Intent, job.History, job.Ensemble)
+		s.swapMu.RLock()
+		if s.active.Load() == set {
+			switch {
+			case response.Success:
+				s.cache.Set(key, response, s.cacheTTL)
+			case s.negativeTTL > 0 && job.Ctx.Err() == nil && s.workCtx.Err() == nil:
+				s.cache.Set(key, response, s.negativeTTL)
+			}
+		}
+		s.swapMu.RUnlock()
+		s.busyWorkers.Add(-1)
 
-			// Lógica existente de chamada à IA (sem cache, pois já tratamos isso antes)
-			// Este bloco será preenchido posteriormente com a lógica real da IA
+		// ResponseChan tem buffer, então o worker nunca bloqueia aqui
+		job.ResponseChan <- response
+	}
+}
 
-			// Simulação de chamada à IA com latência
-			// time.Sleep(3 * time.Second)
+// classify executa o classificador e converte o resultado na resposta da API
+// (veja predict). A chamada respeita o cancelamento de ctx e do encerramento
+// do serviço, com um limite de 10s.
+func (s *FinderService) classify(ctx context.Context, set *classifierSet, classifier Classifier, intent string, history []string) util.FindServiceResponse {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	stop := context.AfterFunc(s.workCtx, cancel)
+	defer stop()
+
+	prediction, err := s.predict(ctx, classifier, intent, history)
+	if err != nil && !errors.Is(err, ErrNoMatch) {
+		return failure(errorCode(err), err.Error())
+	}
 
-			// Placeholder para a resposta da IA
-			// response := util.FindServiceResponse{Success: true, Data: util.ServiceData{ServiceID: 1, ServiceName: "Simulated Service"}}
-			// s.resultChannel <- response
+	// Confiança insuficiente: rotear para atendimento humano em vez de falhar
+	if s.minConfidence > 0 && (err != nil || prediction.Score < s.minConfidence) {
+		prediction.ServiceID = set.res.Catalog.FallbackID()
+		err = nil
+	}
+	if err != nil {
+		// Os candidatos permitem pedir um esclarecimento (veja clarify)
+		response := failure(util.ErrNoMatch, "nenhuma correspondência clara encontrada pela IA para a intenção")
+		response.Candidates = toCandidates(set.res.Catalog, prediction.Candidates)
+		return response
+	}
 
-			// A lógica de chamada da IA e processamento da resposta precisa ser movida para cá.
-			// Por enquanto, vamos manter o esqueleto.
+	response, err := buildResponse(set.res.Catalog, prediction)
+	if err != nil {
+		return failure(util.ErrInternal, err.Error())
+	}
+	s.stats.record(prediction.Stage)
+	return response
+}
 
-			// Reativar o cache (se necessário, mas já fizemos isso)
-			s.mu.RLock()
-			if data, ok := s.cache[job.Intent]; ok {
-				s.mu.RUnlock()
-				job.ResponseChan <- data
-				return // Usar return em vez de continue para sair da função anônima
-			}
-			s.mu.RUnlock()
+// predict classifica primeiro o turno atual sozinho. Só quando o resultado é
+// ambíguo (sem correspondência) ou de confiança baixa (veja needsHistory) e
+// há turnos anteriores, classifica de novo com eles: as etapas lexicais e
+// locais recebem o texto da conversa inteira e a IA, os turnos separados
+// (veja withConversation). Fica a classificação com histórico se ela for
+// pelo menos tão confiante quanto a do turno atual.
+func (s *FinderService) predict(ctx context.Context, classifier Classifier, intent string, history []string) (Prediction, error) {
+	prediction, err := classifier.Classify(ctx, intent)
+	if len(history) == 0 || !s.needsHistory(prediction, err) {
+		return prediction, err
+	}
 
-			// Chamar a IA
-			systemPrompt := getPrompt()
+	util.RequestLogFrom(ctx).Set("history_pass", true)
+	contextual, contextualErr := classifier.Classify(withConversation(ctx, history, intent), contextualIntent(history, intent))
+	if contextualErr == nil && (err != nil || contextual.Score >= prediction.Score) {
+		return contextual, nil
+	}
+	return prediction, err
+}
 
-			responseFormat := &openai.ChatCompletionResponseFormat{
-				Type: openai.ChatCompletionResponseFormatTypeJSONObject,
-			}
+// needsHistory indica se a classificação do turno atual é fraca demais para
+// valer sozinha: sem correspondência ou com confiança abaixo do limiar de
+// esclarecimento ou de fallback, o que for maior. Falhas da classificação
+// (timeout, erro da IA) não são repetidas.
+func (s *FinderService) needsHistory(prediction Prediction, err error) bool {
+	if err != nil {
+		return errors.Is(err, ErrNoMatch)
+	}
+	return prediction.Score < max(s.clarifyThreshold, s.minConfidence)
+}
 
-			resp, err := s.openAIClient.CreateChatCompletion(
-				ctx,
-				openai.ChatCompletionRequest{
-					Model: s.modelName,
-					Messages: []openai.ChatCompletionMessage{
-						{
-							Role:    openai.ChatMessageRoleSystem,
-							Content: systemPrompt,
-						},
-						{
-							Role:    openai.ChatMessageRoleUser,
-							Content: fmt.Sprintf("SOLICITAÇÃO: '%s'\n\nRetorne no formato: {\"service_id\": string, \"service_name\": string}", job.Intent),
-						},
-					},
-					ResponseFormat: responseFormat,
-				},
-			)
-
-			if err != nil {
-				job.ResponseChan <- util.FindServiceResponse{Success: false, Error: fmt.Errorf("erro na chamada à API OpenRouter (ou timeout): %w", err).Error()}
-				return // Usar return em vez de continue para sair da função anônima
-			}
+// failure monta uma resposta de erro.
+func failure(code util.ErrorCode, message string) util.FindServiceResponse {
+	return util.FindServiceResponse{Success: false, Error: message, ErrorCode: code}
+}
 
-			if len(resp.Choices) == 0 {
-				job.ResponseChan <- util.FindServiceResponse{Success: false, Error: "a API OpenRouter não retornou resposta (Choices vazio)"}
-				return // Usar return em vez de continue para sair da função anônima
-			}
+// errorCode mapeia os erros de classificação para os códigos da API.
+func errorCode(err error) util.ErrorCode {
+	switch {
+	case errors.Is(err, ErrNoMatch):
+		return util.ErrNoMatch
+	case errors.Is(err, ErrShuttingDown):
+		return util.ErrShuttingDown
+	case errors.Is(err, ErrInvalidModelOutput):
+		return util.ErrInvalidModelOutput
+	case errors.Is(err, context.DeadlineExceeded):
+		return util.ErrUpstreamTimeout
+	case errors.Is(err, context.Canceled):
+		return util.ErrCanceled
+	default:
+		return util.ErrUpstreamError
+	}
+}
 
-			aiResponseContent := strings.TrimSpace(resp.Choices[0].Message.Content)
-			var aiResponse util.AIResponse
-			if err := json.Unmarshal([]byte(aiResponseContent), &aiResponse); err != nil {
-				fmt.Printf("Erro ao fazer parse do JSON da IA: %v. Conteúdo recebido: %s\n", err, aiResponseContent)
-				job.ResponseChan <- util.FindServiceResponse{Success: false, Error: fmt.Errorf("erro ao decodificar a resposta da IA: %w", err).Error()}
-				return // Usar return em vez de continue para sair da função anônima
-			}
+// buildResponse converte a previsão do classificador na resposta da API,
+// validando os IDs de serviço contra o catálogo.
+func buildResponse(catalog *data.Catalog, prediction Prediction) (util.FindServiceResponse, error) {
+	serviceName, found := catalog.Name(prediction.ServiceID)
+	if !found {
+		return util.FindServiceResponse{}, fmt.Errorf("o ID de serviço retornado pelo classificador (%d) é inválido", prediction.ServiceID)
+	}
 
-			// Adição: Tratar o caso de service_id vazio retornado pelo modelo
-			if aiResponse.ServiceID == "" {
-				job.ResponseChan <- util.FindServiceResponse{Success: false, Error: "nenhuma correspondência clara encontrada pela IA para a intenção"}
-				return
-			}
+	return util.FindServiceResponse{
+		Success: true,
+		Data: util.ServiceData{
+			ServiceID:   prediction.ServiceID,
+			ServiceName: serviceName,
+		},
+		Confidence: prediction.Score,
+		Candidates: toCandidates(catalog, prediction.Candidates),
+		Stage:      prediction.Stage,
+	}, nil
+}
 
-			serviceIDInt, err := strconv.ParseInt(aiResponse.ServiceID, 10, 64)
-			if err != nil {
-				fmt.Printf("Erro ao converter ServiceID da IA para int: %v. Valor recebido: %s\n", err, aiResponse.ServiceID)
-				job.ResponseChan <- util.FindServiceResponse{Success: false, Error: fmt.Errorf("erro ao converter ServiceID da IA para int: %w", err).Error()}
-				return
-			}
+// toCandidates converte os candidatos do classificador, com os nomes do
+// catálogo; IDs fora do catálogo são descartados.
+func toCandidates(catalog *data.Catalog, cands []Candidate) []util.ServiceCandidate {
+	candidates := make([]util.ServiceCandidate, 0, len(cands))
+	for _, c := range cands {
+		if name, ok := catalog.Name(c.ServiceID); ok {
+			candidates = append(candidates, util.ServiceCandidate{
+				ServiceID:   c.ServiceID,
+				ServiceName: name,
+				Confidence:  c.Confidence,
+			})
+		}
+	}
+	return candidates
+}
 
-			serviceName, found := util.ValidServices[int(serviceIDInt)]
-			if !found {
-				job.ResponseChan <- util.FindServiceResponse{Success: false, Error: fmt.Errorf("o ID de serviço retornado pela IA (%d) é inválido. A IA deve usar apenas IDs válidos", serviceIDInt).Error()}
-				return // Usar return em vez de continue para sair da função anônima
-			}
+// FindService usa o cache ou o classificador para classificar a intenção.
+// A intenção é normalizada (veja normalize.Normalize) antes do cache e da
+// classificação, para que variações triviais compartilhem a mesma entrada.
+// Cancelamentos e prazos de ctx se propagam até a chamada à IA.
+//
+// Com histórico (veja FindOptions), a intenção é classificada no contexto
+// dos turnos anteriores; com SessionID, ela é acrescentada à sessão ao final.
+//
+// Com Clarify, intenções ambíguas retornam NEEDS_CLARIFICATION com uma
+// pergunta ao cliente; com ClarificationToken, a intenção é a resposta a essa
+// pergunta (veja resolveClarification).
+func (s *FinderService) FindService(ctx context.Context, intent string, opts FindOptions) util.FindServiceResponse {
+	if opts.ClarificationToken != "" {
+		return s.resolveClarification(ctx, intent, opts)
+	}
+	response := s.findService(ctx, intent, opts)
+	if opts.Clarify {
+		response = s.clarify(response)
+	}
+	return response
+}
 
-			finalServiceData := util.ServiceData{
-				ServiceID:   int(serviceIDInt),
-				ServiceName: serviceName,
-			}
+// findService classifica a intenção, sem esclarecimento.
+func (s *FinderService) findService(ctx context.Context, intent string, opts FindOptions) util.FindServiceResponse {
+	intent = normalize.Normalize(intent)
+	ensemble := s.useEnsemble(opts.Ensemble)
+	history := s.history(opts)
+	key := cacheKey(intent, history, ensemble)
+	if opts.SessionID != "" {
+		defer s.sessions.Append(opts.SessionID, intent)
+	}
 
-			response := util.FindServiceResponse{Success: true, Data: finalServiceData}
+	reqLog := util.RequestLogFrom(ctx)
+	reqLog.Set("intent_hash", util.IntentHash(intent))
+	if len(history) > 0 {
+		reqLog.Set("history_turns", len(history))
+	}
 
-			// Armazenar no cache
-			s.mu.Lock()
-			s.cache[job.Intent] = response
-			s.mu.Unlock()
+	// 1. TENTAR LER DO CACHE (Leitura Rápida)
+	_, span := tracing.Start(ctx, "cache.lookup", tracing.KindInternal)
+	data, ok := s.cache.Get(key)
+	span.Set("ivr.cache_hit", ok)
+	span.End()
+	if ok {
+		s.stats.record(StageCache)
+		reqLog.Set("cache", "hit")
+		data.Stage = StageCache
+		return data // Cache HIT: Retorno instantâneo
+	}
 
-			job.ResponseChan <- response
-		}() // Invocar a função anônima imediatamente
+	// 2. Intenções idênticas já em classificação compartilham a mesma chamada
+	response, shared, err := s.flights.Do(ctx, key, func(ctx context.Context) util.FindServiceResponse {
+		// Não aceitar novos jobs depois que o encerramento começou
+		s.closeMu.RLock()
+		if s.closed {
+			s.closeMu.RUnlock()
+			return failure(util.ErrShuttingDown, ErrShuttingDown.Error())
+		}
+		s.pending.Add(1)
+		s.closeMu.RUnlock()
+		defer s.pending.Done()
+
+		// Enviar a intenção para o canal de jobs e esperar pelo resultado,
+		// desistindo se todos os solicitantes cancelarem
+		job := util.JobRequest{Ctx: ctx, Intent: intent, Ensemble: ensemble, History: history, ResponseChan: make(chan util.FindServiceResponse, 1)}
+		// O canal não tem buffer: o envio termina quando um worker recebe o job
+		_, span := tracing.Start(ctx, "job.queue", tracing.KindInternal)
+		s.queued.Add(1)
+		select {
+		case s.jobChannel <- job:
+			s.queued.Add(-1)
+			span.End()
+		case <-ctx.Done():
+			s.queued.Add(-1)
+			span.SetError(ctx.Err())
+			span.End()
+			return failure(errorCode(ctx.Err()), ctx.Err().Error())
+		}
+
+		select {
+		case response := <-job.ResponseChan:
+			return response
+		case <-ctx.Done():
+			return failure(errorCode(ctx.Err()), ctx.Err().Error())
+		}
+	})
+	if err != nil {
+		return failure(errorCode(err), fmt.Errorf("requisição cancelada: %w", err).Error())
+	}
+	if shared {
+		s.coalesced.Add(1)
+		reqLog.Set("cache", "coalesced")
+	} else {
+		reqLog.Set("cache", "miss")
 	}
+	return response
 }
 
-// FindService usa o cache ou o modelo de IA para classificar a intenção.
-func (s *FinderService) FindService(intent string) util.FindServiceResponse {
-	// 1. TENTAR LER DO CACHE (Leitura Rápida)
-	s.mu.RLock()
-	if data, ok := s.cache[intent]; ok {
-		s.mu.RUnlock()
-		return data // Cache HIT: Retorno instantâneo
+// ShapeResponse ajusta a resposta ao modo solicitado: sem top_k, mantém o
+// formato original (apenas o serviço escolhido); com top_k, inclui a confiança
+// e os K candidatos mais prováveis.
+func ShapeResponse(response util.FindServiceResponse, topK int) util.FindServiceResponse {
+	if topK <= 0 {
+		response.Confidence = 0
+		response.Candidates = nil
+		response.Stage = ""
+		return response
 	}
-	s.mu.RUnlock()
 
-	// Enviar a intenção para o canal de jobs e esperar pelo resultado
-	job := util.JobRequest{Intent: intent, ResponseChan: make(chan util.FindServiceResponse)}
-	s.jobChannel <- job
-	response := <-job.ResponseChan
+	if len(response.Candidates) > topK {
+		response.Candidates = response.Candidates[:topK]
+	}
 	return response
 }
+
+// FindOptions são as opções por requisição de FindService.
+type FindOptions struct {
+	// Ensemble pede (true) ou dispensa (false) o modo ensemble; nil segue o
+	// padrão da implantação. Ignorado se ENSEMBLE_MODE=off.
+	Ensemble *bool
+	// SessionID identifica a ligação (veja SessionStore); vazio não usa sessão.
+	SessionID string
+	// History são os turnos anteriores informados pelo cliente; se presente,
+	// substitui o histórico da sessão.
+	History []string
+	// Clarify troca a falha NO_MATCH (e as respostas de baixa confiança) por
+	// um pedido de esclarecimento, quando há serviços plausíveis.
+	Clarify bool
+	// ClarificationToken é o token do pedido de esclarecimento ao qual a
+	// intenção responde.
+	ClarificationToken string
+}
+
+// useEnsemble decide se a requisição usa o ensemble.
+func (s *FinderService) useEnsemble(requested *bool) bool {
+	if s.active.Load().ensemble == nil {
+		return false
+	}
+	switch s.ensembleMode {
+	case EnsembleOff:
+		return false
+	case EnsembleAlways:
+		return requested == nil || *requested
+	default:
+		return requested != nil && *requested
+	}
+}
+
+// cacheKey separa no cache as respostas do ensemble das da cascata e as
+// classificações com histórico das sem histórico.
+func cacheKey(intent string, history []string, ensemble bool) string {
+	if len(history) > 0 {
+		// Intenções normalizadas não contêm "|"
+		intent = "history:" + strings.Join(history, "|") + "|" + intent
+	}
+	if ensemble {
+		return StageEnsemble + ":" + intent
+	}
+	return intent
+}
+
+// Close encerra o serviço de forma ordenada: recusa novas classificações,
+// aguarda as que estão em andamento (abortando-as se ctx expirar), fecha o
+// canal de jobs, espera os workers terminarem e grava o snapshot do cache.
+func (s *FinderService) Close(ctx context.Context) error {
+	s.closeMu.Lock()
+	if s.closed {
+		s.closeMu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.closeMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		slog.Warn("prazo de encerramento esgotado, abortando classificações em andamento")
+		s.cancelWork()
+		<-drained
+	}
+
+	// Nenhum envio pode estar em andamento aqui, então fechar o canal é seguro
+	close(s.jobChannel)
+	s.wg.Wait()
+	s.cancelWork()
+	close(s.done)
+
+	return s.SaveSnapshot()
+}
+
+// QueueDepth retorna quantos jobs aguardam um worker livre.
+func (s *FinderService) QueueDepth() int64 {
+	return s.queued.Load()
+}
+
+// BusyWorkers retorna quantos workers estão classificando no momento.
+func (s *FinderService) BusyWorkers() int32 {
+	return s.busyWorkers.Load()
+}
+
+// HedgeStats retorna os contadores de chamadas especulativas à IA, se ativas.
+func (s *FinderService) HedgeStats() (llm.HedgeStats, bool) {
+	set := s.active.Load()
+	if set.llm == nil {
+		return llm.HedgeStats{}, false
+	}
+	return set.llm.HedgeStats()
+}
+
+// CoalescedCount retorna quantas requisições reaproveitaram uma classificação
+// já em andamento para a mesma intenção.
+func (s *FinderService) CoalescedCount() uint64 {
+	return s.coalesced.Load()
+}
+
+// responseSize estima o tamanho em memória de uma entrada do cache.
+func responseSize(key string, r util.FindServiceResponse) int64 {
+	const overhead = 160 // struct, elemento da lista e entrada do mapa
+	size := overhead + len(key) + len(r.Data.ServiceName) + len(r.Error) + len(r.Stage)
+	for _, c := range r.Candidates {
+		size += 40 + len(c.ServiceName)
+	}
+	return int64(size)
+}
+
+// CacheStats retorna os contadores do cache de respostas.
+func (s *FinderService) CacheStats() cache.Stats {
+	return s.cache.Stats()
+}
+
+// StageStats retorna quantas respostas cada etapa da cascata (e o cache) produziu.
+func (s *FinderService) StageStats() map[string]uint64 {
+	return s.stats.snapshot()
+}
+
+// stageStats conta as respostas por etapa.
+type stageStats struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+func (st *stageStats) record(stage string) {
+	if stage == "" {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.counts == nil {
+		st.counts = make(map[string]uint64)
+	}
+	st.counts[stage]++
+}
+
+func (st *stageStats) snapshot() map[string]uint64 {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	out := make(map[string]uint64, len(st.counts))
+	for k, v := range st.counts {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"herois-da-pilha/cache"
+	"herois-da-pilha/util"
+)
+
+// recordingClassifier registra as intenções classificadas e responde sempre
+// com o serviço 1.
+type recordingClassifier struct {
+	mu      sync.Mutex
+	intents []string
+}
+
+func (c *recordingClassifier) Classify(_ context.Context, intent string) (Prediction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.intents = append(c.intents, intent)
+	return Prediction{ServiceID: 1, Score: 1, Candidates: []Candidate{{ServiceID: 1, Confidence: 1}}, Stage: StageExact}, nil
+}
+
+func newTestFinder(t *testing.T, classifier Classifier) *FinderService {
+	t.Helper()
+	res, err := LoadResources("", "", "", 12)
+	if err != nil {
+		t.Fatalf("LoadResources: %v", err)
+	}
+	return NewFinderServiceWith(res, classifier, cache.NewLRU(cache.Options[util.FindServiceResponse]{}))
+}
+
+func TestWorkerSkipsAbandonedJob(t *testing.T) {
+	classifier := &recordingClassifier{}
+	s := newTestFinder(t, classifier)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	abandoned := util.JobRequest{Ctx: ctx, Intent: "abandonada", ResponseChan: make(chan util.FindServiceResponse, 1)}
+	s.jobChannel <- abandoned
+
+	live := util.JobRequest{Ctx: context.Background(), Intent: "ativa", ResponseChan: make(chan util.FindServiceResponse, 1)}
+	s.jobChannel <- live
+	if resp := <-live.ResponseChan; !resp.Success {
+		t.Fatalf("job ativo falhou: %+v", resp)
+	}
+
+	// Close espera os workers, então o job abandonado já foi descartado
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(classifier.intents) != 1 || classifier.intents[0] != "ativa" {
+		t.Errorf("intenções classificadas = %v, want apenas a ativa", classifier.intents)
+	}
+	if len(abandoned.ResponseChan) != 0 {
+		t.Error("o job abandonado recebeu resposta")
+	}
+	if _, ok := s.cache.Get(cacheKey("abandonada", nil, false)); ok {
+		t.Error("o job abandonado foi armazenado no cache")
+	}
+}
+
+// scoredClassifier responde com o serviço 3 e a confiança informada, ou
+// com ErrNoMatch se noMatch.
+type scoredClassifier struct {
+	score   float64
+	noMatch bool
+}
+
+func (c scoredClassifier) Classify(context.Context, string) (Prediction, error) {
+	if c.noMatch {
+		return Prediction{Candidates: []Candidate{{ServiceID: 3, Confidence: c.score}}}, ErrNoMatch
+	}
+	return Prediction{ServiceID: 3, Score: c.score, Candidates: []Candidate{{ServiceID: 3, Confidence: c.score}}, Stage: StageLocal}, nil
+}
+
+func TestHumanFallbackThreshold(t *testing.T) {
+	tests := []struct {
+		name       string
+		classifier scoredClassifier
+		threshold  float64
+		want       int
+	}{
+		{"acima do limite", scoredClassifier{score: 0.8}, 0.5, 3},
+		{"abaixo do limite", scoredClassifier{score: 0.3}, 0.5, 15},
+		{"sem correspondência", scoredClassifier{score: 0.3, noMatch: true}, 0.5, 15},
+	}
+	for _, tt := range tests {
+		s := newTestFinder(t, tt.classifier)
+		s.minConfidence = tt.threshold
+		resp := s.FindService(context.Background(), "intenção", FindOptions{})
+		if !resp.Success || resp.Data.ServiceID != tt.want {
+			t.Errorf("%s: %+v, want sucesso com o serviço %d", tt.name, resp, tt.want)
+		}
+		s.Close(context.Background())
+	}
+
+	// Sem o limite, a falta de correspondência continua sendo NO_MATCH
+	s := newTestFinder(t, scoredClassifier{noMatch: true})
+	defer s.Close(context.Background())
+	if resp := s.FindService(context.Background(), "intenção", FindOptions{}); resp.Success || resp.ErrorCode != util.ErrNoMatch {
+		t.Errorf("sem limite: %+v, want NO_MATCH", resp)
+	}
+}
+
+func TestShapeResponse(t *testing.T) {
+	res, err := LoadResources("", "", "", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestFinder(t, NewLocalClassifier(res.Examples))
+	defer s.Close(context.Background())
+	resp := s.FindService(context.Background(), "perdi meu cartão", FindOptions{})
+	if !resp.Success || len(resp.Candidates) < 3 {
+		t.Fatalf("resposta = %+v, want sucesso com ao menos 3 candidatos", resp)
+	}
+
+	// top_k=0 mantém exatamente o formato original da API
+	raw, _ := json.Marshal(ShapeResponse(resp, 0))
+	want := fmt.Sprintf(`{"success":true,"data":{"service_id":%d,"service_name":%q}}`, resp.Data.ServiceID, resp.Data.ServiceName)
+	if string(raw) != want {
+		t.Errorf("top_k=0: %s, want %s", raw, want)
+	}
+
+	shaped := ShapeResponse(resp, 2)
+	if len(shaped.Candidates) != 2 || shaped.Confidence == 0 {
+		t.Fatalf("top_k=2: %+v, want 2 candidatos com confiança", shaped)
+	}
+	if shaped.Candidates[0].ServiceID != resp.Data.ServiceID || shaped.Candidates[0].Confidence < shaped.Candidates[1].Confidence {
+		t.Errorf("candidatos fora de ordem: %+v", shaped.Candidates)
+	}
+}
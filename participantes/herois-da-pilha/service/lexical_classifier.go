@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"herois-da-pilha/data"
+	"herois-da-pilha/normalize"
+)
+
+// ExactClassifier responde apenas quando a intenção normalizada é idêntica a
+// um exemplo do dataset. Exemplos idênticos com serviços diferentes são
+// descartados por serem ambíguos.
+type ExactClassifier struct {
+	index map[string]int
+}
+
+// NewExactClassifier indexa os exemplos pelo texto normalizado.
+func NewExactClassifier(examples []data.IntentExample) *ExactClassifier {
+	index := make(map[string]int, len(examples))
+	for _, ex := range examples {
+		key := normalize.Normalize(ex.Intent)
+		if id, ok := index[key]; ok && id != ex.ServiceID {
+			index[key] = 0 // ambíguo
+			continue
+		}
+		index[key] = ex.ServiceID
+	}
+	return &ExactClassifier{index: index}
+}
+
+// Classify implementa Classifier.
+func (c *ExactClassifier) Classify(_ context.Context, intent string) (Prediction, error) {
+	id := c.index[normalize.Normalize(intent)]
+	if id == 0 {
+		return Prediction{}, ErrNoMatch
+	}
+	return Prediction{
+		ServiceID:  id,
+		Score:      1,
+		Candidates: []Candidate{{ServiceID: id, Confidence: 1}},
+	}, nil
+}
+
+// FuzzyClassifier compara os tokens da intenção com os de cada exemplo usando
+// o coeficiente de Dice ponderado por IDF (palavras raras como "pagar" pesam
+// mais que "quero" ou "do"), tolerando pequenos erros de digitação por token.
+// O Score é a similaridade com o exemplo mais próximo.
+type FuzzyClassifier struct {
+	examples []tokenizedExample
+	idf      map[string]float64
+	maxIDF   float64
+}
+
+type tokenizedExample struct {
+	serviceID int
+	tokens    []string
+}
+
+// minTokenSimilarity é a similaridade mínima (1 - distância de edição
+// relativa) para dois tokens serem considerados iguais.
+const minTokenSimilarity = 0.8
+
+// NewFuzzyClassifier tokeniza os exemplos informados.
+func NewFuzzyClassifier(examples []data.IntentExample) *FuzzyClassifier {
+	c := &FuzzyClassifier{
+		examples: make([]tokenizedExample, 0, len(examples)),
+		idf:      make(map[string]float64),
+	}
+
+	df := make(map[string]int)
+	for _, ex := range examples {
+		tokens := strings.Fields(normalize.Normalize(ex.Intent))
+		c.examples = append(c.examples, tokenizedExample{serviceID: ex.ServiceID, tokens: tokens})
+
+		seen := make(map[string]bool, len(tokens))
+		for _, t := range tokens {
+			if !seen[t] {
+				seen[t] = true
+				df[t]++
+			}
+		}
+	}
+
+	n := float64(len(examples))
+	c.maxIDF = math.Log(n+1) + 1
+	for t, d := range df {
+		c.idf[t] = math.Log((n+1)/(float64(d)+1)) + 1
+	}
+	return c
+}
+
+// weight retorna o peso IDF do token; tokens desconhecidos recebem o peso máximo.
+func (c *FuzzyClassifier) weight(token string) float64 {
+	if w, ok := c.idf[token]; ok {
+		return w
+	}
+	return c.maxIDF
+}
+
+// Classify implementa Classifier.
+func (c *FuzzyClassifier) Classify(_ context.Context, intent string) (Prediction, error) {
+	tokens := strings.Fields(normalize.Normalize(intent))
+	if len(tokens) == 0 {
+		return Prediction{}, ErrNoMatch
+	}
+
+	best := make(map[int]float64)
+	for _, ex := range c.examples {
+		if sim := c.diceSimilarity(tokens, ex.tokens); sim > best[ex.serviceID] {
+			best[ex.serviceID] = sim
+		}
+	}
+
+	candidates := make([]Candidate, 0, len(best))
+	for id, sim := range best {
+		if sim > 0 {
+			candidates = append(candidates, Candidate{ServiceID: id, Confidence: sim})
+		}
+	}
+	if len(candidates) == 0 {
+		return Prediction{}, ErrNoMatch
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].ServiceID < candidates[j].ServiceID
+	})
+
+	return Prediction{
+		ServiceID:  candidates[0].ServiceID,
+		Score:      candidates[0].Confidence,
+		Candidates: candidates,
+	}, nil
+}
+
+// diceSimilarity calcula 2*w(A∩B) / (w(A)+w(B)), pareando cada token de a com
+// no máximo um token de b. O peso de um par é o do token do exemplo (b).
+func (c *FuzzyClassifier) diceSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	var total, matched float64
+	for _, tb := range b {
+		total += c.weight(tb)
+	}
+
+	used := make([]bool, len(b))
+	for _, ta := range a {
+		total += c.weight(ta)
+		for j, tb := range b {
+			if !used[j] && tokenSimilarity(ta, tb) >= minTokenSimilarity {
+				used[j] = true
+				matched += c.weight(ta) + c.weight(tb)
+				break
+			}
+		}
+	}
+	return matched / total
+}
+
+// tokenSimilarity retorna 1 - distância de Levenshtein / tamanho do maior token.
+func tokenSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"herois-da-pilha/data"
+)
+
+var lexicalExamples = []data.IntentExample{
+	{ServiceID: 3, Intent: "segunda via da fatura"},
+	{ServiceID: 13, Intent: "quero pagar uma conta"},
+	{ServiceID: 11, Intent: "perdi meu cartão"},
+	{ServiceID: 7, Intent: "cancelar cartão"},
+	{ServiceID: 5, Intent: "cancelar cartão"},
+}
+
+func TestExactClassifier(t *testing.T) {
+	c := NewExactClassifier(lexicalExamples)
+	tests := []struct {
+		intent string
+		want   int
+	}{
+		{"Perdi meu CARTÃO!", 11},
+		{"segunda via da fatura", 3},
+		{"segunda via fatura", 0},
+		{"cancelar cartão", 0}, // exemplo idêntico em dois serviços
+	}
+	for _, tt := range tests {
+		pred, err := c.Classify(context.Background(), tt.intent)
+		if tt.want == 0 {
+			if !errors.Is(err, ErrNoMatch) {
+				t.Errorf("%q: %+v, %v; want ErrNoMatch", tt.intent, pred, err)
+			}
+			continue
+		}
+		if err != nil || pred.ServiceID != tt.want || pred.Score != 1 {
+			t.Errorf("%q: %+v, %v; want serviço %d com confiança 1", tt.intent, pred, err, tt.want)
+		}
+	}
+}
+
+func TestTokenSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"fatura", "fatura", 1},
+		{"fatura", "fatora", 1 - 1.0/6},
+		{"cartao", "catao", 1 - 1.0/6},
+		{"conta", "pagar", 0},
+		{"", "abc", 0},
+	}
+	for _, tt := range tests {
+		if got := tokenSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("tokenSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if d := levenshtein([]rune("kitten"), []rune("sitting")); d != 3 {
+		t.Errorf("levenshtein(kitten, sitting) = %d, want 3", d)
+	}
+}
+
+func TestFuzzyClassifier(t *testing.T) {
+	c := NewFuzzyClassifier(lexicalExamples)
+
+	// Dice ponderado por IDF: a mesma frase com um erro de digitação pareia
+	// todos os tokens
+	pred, err := c.Classify(context.Background(), "segunda via da fatora")
+	if err != nil || pred.ServiceID != 3 || math.Abs(pred.Score-1) > 1e-9 {
+		t.Errorf("erro de digitação: %+v, %v; want serviço 3 com similaridade 1", pred, err)
+	}
+
+	// Faltando um token, a similaridade é 2*w(A∩B) / (w(A)+w(B))
+	pred, err = c.Classify(context.Background(), "perdi cartão")
+	if err != nil || pred.ServiceID != 11 {
+		t.Fatalf("token faltando: %+v, %v; want serviço 11", pred, err)
+	}
+	wPerdi, wMeu, wCartao := c.weight("perdi"), c.weight("meu"), c.weight("cartao")
+	want := 2 * (wPerdi + wCartao) / (2*(wPerdi+wCartao) + wMeu)
+	if math.Abs(pred.Score-want) > 1e-9 {
+		t.Errorf("similaridade = %v, want %v", pred.Score, want)
+	}
+
+	// Tokens raros pesam mais que os comuns
+	if c.weight("cartao") >= c.weight("fatura") || c.weight("desconhecido") != c.maxIDF {
+		t.Errorf("pesos: cartao=%v fatura=%v desconhecido=%v", c.weight("cartao"), c.weight("fatura"), c.weight("desconhecido"))
+	}
+
+	for i := 1; i < len(pred.Candidates); i++ {
+		if pred.Candidates[i].Confidence > pred.Candidates[i-1].Confidence {
+			t.Errorf("candidatos fora de ordem: %+v", pred.Candidates)
+		}
+	}
+
+	if _, err := c.Classify(context.Background(), "bolo de chocolate"); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("sem tokens em comum: err = %v, want ErrNoMatch", err)
+	}
+}
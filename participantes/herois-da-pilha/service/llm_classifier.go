@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"herois-da-pilha/data"
+	"herois-da-pilha/llm"
+	"herois-da-pilha/util"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// LLMClassifier classifica intenções chamando um modelo de linguagem através
+// de um llm.Provider (tipicamente um llm.Failover sobre modelos do OpenRouter).
+type LLMClassifier struct {
+	provider llm.Provider
+	catalog  *data.Catalog
+	prompts  *PromptBuilder
+
+	// DefaultConfidence é a confiança atribuída à resposta quando o provedor
+	// não retorna logprobs.
+	DefaultConfidence float64
+}
+
+// ErrInvalidModelOutput indica que a resposta do modelo não pôde ser usada
+// (JSON inválido, ID fora do catálogo etc.).
+var ErrInvalidModelOutput = errors.New("resposta inválida da IA")
+
+// maxTopLogProbs é o máximo de alternativas por token aceito pela API.
+const maxTopLogProbs = 5
+
+// NewLLMClassifier cria um classificador que usa o provedor informado. As
+// respostas são validadas contra o catálogo e os prompts montados por prompts.
+func NewLLMClassifier(provider llm.Provider, catalog *data.Catalog, prompts *PromptBuilder) *LLMClassifier {
+	return &LLMClassifier{provider: provider, catalog: catalog, prompts: prompts, DefaultConfidence: 0.9}
+}
+
+// HasAPIKey indica se a chave da API foi configurada.
+func (c *LLMClassifier) HasAPIKey() bool {
+	if p, ok := c.provider.(llm.Prober); ok {
+		return p.HasAPIKey()
+	}
+	return true
+}
+
+// Probe verifica se o provedor está acessível, se ele suportar a verificação.
+func (c *LLMClassifier) Probe(ctx context.Context) error {
+	if p, ok := c.provider.(llm.Prober); ok {
+		return p.Probe(ctx)
+	}
+	return nil
+}
+
+// BreakerStates retorna o estado dos circuit breakers do provedor, se houver.
+func (c *LLMClassifier) BreakerStates() map[string]string {
+	if r, ok := c.provider.(interface{ BreakerStates() map[string]string }); ok {
+		return r.BreakerStates()
+	}
+	return nil
+}
+
+// HedgeStats retorna os contadores de hedge, se o hedge estiver ativo.
+func (c *LLMClassifier) HedgeStats() (llm.HedgeStats, bool) {
+	if h, ok := c.provider.(*llm.Hedged); ok {
+		return h.HedgeStats(), true
+	}
+	return llm.HedgeStats{}, false
+}
+
+// Classify implementa Classifier.
+func (c *LLMClassifier) Classify(ctx context.Context, intent string) (Prediction, error) {
+	// Com histórico, intent é o texto da conversa inteira; o prompt separa
+	// os turnos anteriores da solicitação atual.
+	var history []string
+	if conv, ok := conversationFrom(ctx); ok {
+		history, intent = conv.history, conv.current
+	}
+	systemPrompt, userPrompt, err := c.prompts.BuildWithHistory(history, intent)
+	if err != nil {
+		return Prediction{}, err
+	}
+
+	responseFormat := &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+	}
+
+	start := time.Now()
+	resp, err := c.provider.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: systemPrompt,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: userPrompt,
+				},
+			},
+			ResponseFormat: responseFormat,
+			LogProbs:       true,
+			TopLogProbs:    maxTopLogProbs,
+		},
+	)
+	if errors.Is(err, llm.ErrRejected) {
+		return Prediction{}, fmt.Errorf("%w: %w", ErrInvalidModelOutput, err)
+	}
+	if err != nil {
+		return Prediction{}, fmt.Errorf("erro na chamada à API OpenRouter (ou timeout): %w", err)
+	}
+	util.RequestLogFrom(ctx).AddUpstream(resp.Model, time.Since(start), resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+
+	prediction, err := c.parseResponse(resp)
+	if err != nil && !errors.Is(err, ErrNoMatch) {
+		slog.WarnContext(ctx, "resposta inválida da IA", "error", err, "model", resp.Model, "content", responseContent(resp))
+	}
+	return prediction, err
+}
+
+// acceptResponse indica se a resposta do modelo é bem formada (um serviço
+// válido ou a indicação explícita de nenhuma correspondência). Usada para
+// decidir a disputa entre chamadas no hedge.
+func (c *LLMClassifier) acceptResponse(resp openai.ChatCompletionResponse) bool {
+	_, err := c.parseResponse(resp)
+	return err == nil || errors.Is(err, ErrNoMatch)
+}
+
+func responseContent(resp openai.ChatCompletionResponse) string {
+	if len(resp.Choices) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content)
+}
+
+// parseResponse converte a resposta do modelo em uma previsão.
+func (c *LLMClassifier) parseResponse(resp openai.ChatCompletionResponse) (Prediction, error) {
+	if len(resp.Choices) == 0 {
+		return Prediction{}, fmt.Errorf("%w: a API OpenRouter não retornou resposta (Choices vazio)", ErrInvalidModelOutput)
+	}
+
+	var aiResponse util.AIResponse
+	if err := json.Unmarshal([]byte(responseContent(resp)), &aiResponse); err != nil {
+		return Prediction{}, fmt.Errorf("%w: erro ao decodificar JSON: %w", ErrInvalidModelOutput, err)
+	}
+
+	// Tratar o caso de service_id vazio retornado pelo modelo, mantendo os
+	// serviços plausíveis que ele indicar
+	if aiResponse.ServiceID == "" {
+		return Prediction{Candidates: c.plausibleCandidates(aiResponse.Candidates)}, fmt.Errorf("%w pela IA", ErrNoMatch)
+	}
+
+	serviceIDInt, err := strconv.ParseInt(aiResponse.ServiceID, 10, 64)
+	if err != nil {
+		return Prediction{}, fmt.Errorf("%w: erro ao converter ServiceID para int: %w", ErrInvalidModelOutput, err)
+	}
+
+	if _, found := c.catalog.Lookup(int(serviceIDInt)); !found {
+		return Prediction{}, fmt.Errorf("%w: o ID de serviço retornado (%d) não está no catálogo", ErrInvalidModelOutput, serviceIDInt)
+	}
+
+	candidates := candidatesFromLogProbs(c.catalog, resp.Choices[0].LogProbs, aiResponse.ServiceID)
+	if len(candidates) == 0 || candidates[0].ServiceID != int(serviceIDInt) {
+		candidates = []Candidate{{ServiceID: int(serviceIDInt), Confidence: c.DefaultConfidence}}
+	}
+
+	return Prediction{
+		ServiceID:  int(serviceIDInt),
+		Score:      candidates[0].Confidence,
+		Candidates: candidates,
+	}, nil
+}
+
+// plausibleCandidates converte os IDs indicados pelo modelo em dúvida em
+// candidatos de confiança igual, ignorando os inválidos e os repetidos.
+func (c *LLMClassifier) plausibleCandidates(ids []string) []Candidate {
+	var candidates []Candidate
+	seen := make(map[int]bool, len(ids))
+	for _, raw := range ids {
+		id, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || seen[id] {
+			continue
+		}
+		if _, found := c.catalog.Lookup(id); !found {
+			continue
+		}
+		seen[id] = true
+		candidates = append(candidates, Candidate{ServiceID: id})
+	}
+	for i := range candidates {
+		candidates[i].Confidence = 1 / float64(len(candidates))
+	}
+	return candidates
+}
+
+// candidatesFromLogProbs extrai a distribuição de probabilidade do token do
+// service_id a partir dos logprobs retornados pelo modelo.
+//
+// Supõe que o ID inteiro é um único token, o que vale para os tokenizadores
+// usuais enquanto os IDs tiverem no máximo dois dígitos: as alternativas
+// daquela posição são então IDs completos. Se o modelo quebrar o ID em vários
+// tokens (ex: "1" e "6" para 16), nenhum token coincide com o ID e a função
+// retorna nil, porque as alternativas do primeiro token seriam só prefixos;
+// o chamador usa então DefaultConfidence. Catálogos com IDs maiores perdem a
+// distribuição de candidatos, mas continuam classificando.
+func candidatesFromLogProbs(catalog *data.Catalog, logProbs *openai.LogProbs, serviceID string) []Candidate {
+	if logProbs == nil {
+		return nil
+	}
+
+	for _, lp := range logProbs.Content {
+		if strings.TrimSpace(lp.Token) != serviceID {
+			continue
+		}
+
+		chosen, _ := strconv.Atoi(serviceID)
+		candidates := []Candidate{{ServiceID: chosen, Confidence: math.Exp(lp.LogProb)}}
+		seen := map[int]bool{chosen: true}
+		for _, top := range lp.TopLogProbs {
+			id, err := strconv.Atoi(strings.TrimSpace(top.Token))
+			if err != nil || seen[id] {
+				continue
+			}
+			if _, found := catalog.Lookup(id); !found {
+				continue
+			}
+			seen[id] = true
+			candidates = append(candidates, Candidate{ServiceID: id, Confidence: math.Exp(top.LogProb)})
+		}
+		sortCandidates(candidates[1:])
+		return candidates
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestCandidatesFromLogProbs(t *testing.T) {
+	res, err := LoadResources("", "", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := func(ts ...openai.LogProb) *openai.LogProbs {
+		return &openai.LogProbs{Content: ts}
+	}
+	alt := func(token string, p float64) openai.TopLogProbs {
+		return openai.TopLogProbs{Token: token, LogProb: math.Log(p)}
+	}
+
+	// ID em um único token: as alternativas da posição viram candidatos,
+	// ignorando as que não são IDs do catálogo
+	single := tokens(
+		openai.LogProb{Token: `{"service_id": "`},
+		openai.LogProb{Token: "13", LogProb: math.Log(0.7), TopLogProbs: []openai.TopLogProbs{
+			alt("13", 0.7), alt("3", 0.2), alt("99", 0.05), alt("x", 0.01), alt("2", 0.04),
+		}},
+	)
+	got := candidatesFromLogProbs(res.Catalog, single, "13")
+	want := []int{13, 3, 2}
+	if len(got) != len(want) {
+		t.Fatalf("candidatos = %+v, want IDs %v", got, want)
+	}
+	for i, id := range want {
+		if got[i].ServiceID != id {
+			t.Errorf("candidatos[%d] = %d, want %d", i, got[i].ServiceID, id)
+		}
+	}
+	if math.Abs(got[0].Confidence-0.7) > 1e-9 {
+		t.Errorf("confiança = %v, want 0.7", got[0].Confidence)
+	}
+
+	// ID quebrado em dois tokens: sem distribuição confiável
+	split := tokens(
+		openai.LogProb{Token: "1", TopLogProbs: []openai.TopLogProbs{alt("1", 0.9)}},
+		openai.LogProb{Token: "6"},
+	)
+	if got := candidatesFromLogProbs(res.Catalog, split, "16"); got != nil {
+		t.Errorf("ID em vários tokens: candidatos = %+v, want nil", got)
+	}
+
+	if got := candidatesFromLogProbs(res.Catalog, nil, "13"); got != nil {
+		t.Errorf("sem logprobs: candidatos = %+v, want nil", got)
+	}
+}
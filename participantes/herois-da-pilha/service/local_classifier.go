@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"herois-da-pilha/data"
+	"herois-da-pilha/normalize"
+)
+
+// LocalClassifier é um classificador offline baseado em vetores TF-IDF de
+// n-gramas de caracteres, construídos a partir do dataset de intenções.
+// A classificação é feita por vizinho mais próximo (similaridade de cosseno)
+// e roda em microssegundos, sem nenhuma chamada de rede.
+type LocalClassifier struct {
+	vocab    map[string]int
+	idf      []float64
+	examples []indexedExample
+
+	// Temperature e NoneScore calibram as similaridades em probabilidades
+	// (veja calibrate).
+	Temperature float64
+	NoneScore   float64
+}
+
+type indexedExample struct {
+	source data.IntentExample
+	vector sparseVector
+}
+
+// sparseVector é um vetor esparso normalizado (L2), ordenado por índice de feature.
+type sparseVector []feature
+
+type feature struct {
+	index  int
+	weight float64
+}
+
+// Tamanhos dos n-gramas de caracteres usados como features.
+const (
+	minNGram = 3
+	maxNGram = 4
+)
+
+// Valores default de calibração do LocalClassifier.
+const (
+	defaultTemperature = 0.05
+	defaultNoneScore   = 0.35
+)
+
+// NewLocalClassifier constrói o índice TF-IDF a partir dos exemplos informados.
+func NewLocalClassifier(examples []data.IntentExample) *LocalClassifier {
+	c := &LocalClassifier{
+		vocab:       make(map[string]int),
+		Temperature: defaultTemperature,
+		NoneScore:   defaultNoneScore,
+	}
+
+	// 1. Vocabulário e frequência de documentos
+	docs := make([]map[string]int, len(examples))
+	var df []int
+	for i, ex := range examples {
+		docs[i] = extractNGrams(normalize.Normalize(ex.Intent))
+		for gram := range docs[i] {
+			idx, ok := c.vocab[gram]
+			if !ok {
+				idx = len(c.vocab)
+				c.vocab[gram] = idx
+				df = append(df, 0)
+			}
+			df[idx]++
+		}
+	}
+
+	// 2. IDF suavizado
+	n := float64(len(examples))
+	c.idf = make([]float64, len(df))
+	for i, d := range df {
+		c.idf[i] = math.Log((n+1)/(float64(d)+1)) + 1
+	}
+
+	// 3. Vetores dos exemplos
+	c.examples = make([]indexedExample, 0, len(examples))
+	for i, ex := range examples {
+		c.examples = append(c.examples, indexedExample{
+			source: ex,
+			vector: c.vectorize(docs[i]),
+		})
+	}
+
+	return c
+}
+
+// Classify implementa Classifier. Cada serviço recebe a similaridade de
+// cosseno do seu exemplo mais próximo, que é então calibrada em probabilidade.
+func (c *LocalClassifier) Classify(_ context.Context, intent string) (Prediction, error) {
+	query := c.vectorize(extractNGrams(normalize.Normalize(intent)))
+	if len(query) == 0 {
+		return Prediction{}, ErrNoMatch
+	}
+
+	similarities := make(map[int]float64)
+	for _, ex := range c.examples {
+		if sim := dot(query, ex.vector); sim > similarities[ex.source.ServiceID] {
+			similarities[ex.source.ServiceID] = sim
+		}
+	}
+
+	candidates := c.calibrate(similarities)
+	if len(candidates) == 0 {
+		return Prediction{}, ErrNoMatch
+	}
+
+	return Prediction{
+		ServiceID:  candidates[0].ServiceID,
+		Score:      candidates[0].Confidence,
+		Candidates: candidates,
+	}, nil
+}
+
+// Nearest retorna os n exemplos mais parecidos com a intenção, do mais
+// parecido para o menos parecido. Exemplos sem nenhuma semelhança são omitidos.
+func (c *LocalClassifier) Nearest(intent string, n int) []data.IntentExample {
+	query := c.vectorize(extractNGrams(normalize.Normalize(intent)))
+	if len(query) == 0 || n <= 0 {
+		return nil
+	}
+
+	type scored struct {
+		example data.IntentExample
+		sim     float64
+	}
+	ranked := make([]scored, 0, len(c.examples))
+	for _, ex := range c.examples {
+		if sim := dot(query, ex.vector); sim > 0 {
+			ranked = append(ranked, scored{example: ex.source, sim: sim})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].sim > ranked[j].sim })
+
+	nearest := make([]data.IntentExample, 0, min(n, len(ranked)))
+	for i := 0; i < len(ranked) && i < n; i++ {
+		nearest = append(nearest, ranked[i].example)
+	}
+	return nearest
+}
+
+// calibrate converte similaridades em probabilidades via softmax com
+// temperatura. Uma classe fictícia "nenhum serviço" com similaridade NoneScore
+// absorve a massa de probabilidade quando nenhum exemplo é realmente parecido,
+// de modo que similaridades baixas resultam em confiança baixa.
+func (c *LocalClassifier) calibrate(similarities map[int]float64) []Candidate {
+	candidates := make([]Candidate, 0, len(similarities))
+	for id, sim := range similarities {
+		if sim > 0 {
+			candidates = append(candidates, Candidate{ServiceID: id, Confidence: sim})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// Subtrai o máximo antes da exponencial para estabilidade numérica
+	maxSim := c.NoneScore
+	for _, cand := range candidates {
+		maxSim = math.Max(maxSim, cand.Confidence)
+	}
+
+	total := math.Exp((c.NoneScore - maxSim) / c.Temperature)
+	for i := range candidates {
+		candidates[i].Confidence = math.Exp((candidates[i].Confidence - maxSim) / c.Temperature)
+		total += candidates[i].Confidence
+	}
+	for i := range candidates {
+		candidates[i].Confidence /= total
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].ServiceID < candidates[j].ServiceID
+	})
+	return candidates
+}
+
+// vectorize converte contagens de n-gramas em um vetor TF-IDF normalizado.
+// N-gramas fora do vocabulário são ignorados.
+func (c *LocalClassifier) vectorize(grams map[string]int) sparseVector {
+	vec := make(sparseVector, 0, len(grams))
+	var norm float64
+	for gram, count := range grams {
+		idx, ok := c.vocab[gram]
+		if !ok {
+			continue
+		}
+		w := (1 + math.Log(float64(count))) * c.idf[idx]
+		vec = append(vec, feature{index: idx, weight: w})
+		norm += w * w
+	}
+	if norm == 0 {
+		return nil
+	}
+
+	norm = math.Sqrt(norm)
+	for i := range vec {
+		vec[i].weight /= norm
+	}
+	sort.Slice(vec, func(i, j int) bool { return vec[i].index < vec[j].index })
+	return vec
+}
+
+// dot calcula o produto escalar de dois vetores esparsos ordenados.
+func dot(a, b sparseVector) float64 {
+	var sum float64
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].index == b[j].index:
+			sum += a[i].weight * b[j].weight
+			i++
+			j++
+		case a[i].index < b[j].index:
+			i++
+		default:
+			j++
+		}
+	}
+	return sum
+}
+
+// extractNGrams extrai os n-gramas de caracteres de cada palavra (com marcadores de borda).
+func extractNGrams(text string) map[string]int {
+	grams := make(map[string]int)
+	for _, word := range strings.Fields(text) {
+		runes := []rune(" " + word + " ")
+		for n := minNGram; n <= maxNGram; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				grams[string(runes[i:i+n])]++
+			}
+		}
+	}
+	return grams
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"herois-da-pilha/data"
+	"herois-da-pilha/normalize"
+)
+
+func newTestLocalClassifier(t *testing.T) (*LocalClassifier, []data.IntentExample) {
+	t.Helper()
+	examples, err := data.LoadIntentExamples("")
+	if err != nil {
+		t.Fatalf("LoadIntentExamples: %v", err)
+	}
+	return NewLocalClassifier(examples), examples
+}
+
+func TestLocalClassifierDatasetIntents(t *testing.T) {
+	c, examples := newTestLocalClassifier(t)
+	for _, ex := range examples {
+		pred, err := c.Classify(context.Background(), ex.Intent)
+		if err != nil {
+			t.Errorf("%q: %v", ex.Intent, err)
+			continue
+		}
+		if pred.ServiceID != ex.ServiceID {
+			t.Errorf("%q: serviço %d, want %d", ex.Intent, pred.ServiceID, ex.ServiceID)
+		}
+	}
+}
+
+func TestLocalClassifierOutOfDomain(t *testing.T) {
+	c, _ := newTestLocalClassifier(t)
+	for _, intent := range []string{"time de futebol favorito", "meu cachorro fugiu", "xyzw", "!!!"} {
+		pred, err := c.Classify(context.Background(), intent)
+		if errors.Is(err, ErrNoMatch) {
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", intent, err)
+			continue
+		}
+		if pred.Score >= 0.5 {
+			t.Errorf("%q: serviço %d com confiança %.2f, want confiança baixa", intent, pred.ServiceID, pred.Score)
+		}
+	}
+}
+
+func TestLocalClassifierCalibration(t *testing.T) {
+	c := &LocalClassifier{Temperature: 0.05, NoneScore: 0.35}
+	tests := []struct {
+		name         string
+		similarities map[int]float64
+	}{
+		{"um claro", map[int]float64{1: 0.9, 2: 0.3}},
+		{"empate", map[int]float64{3: 0.6, 4: 0.6}},
+		{"todos fracos", map[int]float64{5: 0.1, 6: 0.05}},
+	}
+	for _, tt := range tests {
+		candidates := c.calibrate(tt.similarities)
+
+		// A classe "nenhum serviço" fica com o restante da massa
+		maxSim := c.NoneScore
+		for _, sim := range tt.similarities {
+			maxSim = math.Max(maxSim, sim)
+		}
+		none := math.Exp((c.NoneScore - maxSim) / c.Temperature)
+		total := none
+		for _, sim := range tt.similarities {
+			total += math.Exp((sim - maxSim) / c.Temperature)
+		}
+
+		sum := none / total
+		for i, cand := range candidates {
+			sum += cand.Confidence
+			if i > 0 && cand.Confidence > candidates[i-1].Confidence {
+				t.Errorf("%s: candidatos fora de ordem: %+v", tt.name, candidates)
+			}
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("%s: probabilidades somam %v, want 1", tt.name, sum)
+		}
+	}
+
+	if weak := c.calibrate(map[int]float64{5: 0.1}); weak[0].Confidence >= 0.5 {
+		t.Errorf("similaridade abaixo de NoneScore: confiança %.2f, want < 0.5", weak[0].Confidence)
+	}
+	if c.calibrate(map[int]float64{1: 0}) != nil {
+		t.Error("similaridades nulas deveriam resultar em nenhum candidato")
+	}
+}
+
+func TestLocalClassifierNearest(t *testing.T) {
+	c, examples := newTestLocalClassifier(t)
+	intent := examples[0].Intent
+
+	nearest := c.Nearest(intent, 5)
+	if len(nearest) != 5 {
+		t.Fatalf("Nearest retornou %d exemplos, want 5", len(nearest))
+	}
+	if nearest[0].Intent != intent {
+		t.Errorf("mais próximo = %q, want o próprio exemplo %q", nearest[0].Intent, intent)
+	}
+
+	query := c.vectorize(extractNGrams(normalize.Normalize(intent)))
+	prev := math.Inf(1)
+	for _, ex := range nearest {
+		sim := dot(query, c.vectorize(extractNGrams(normalize.Normalize(ex.Intent))))
+		if sim > prev+1e-9 {
+			t.Errorf("Nearest fora de ordem em %q (%.3f > %.3f)", ex.Intent, sim, prev)
+		}
+		prev = sim
+	}
+
+	if got := c.Nearest(intent, 0); got != nil {
+		t.Errorf("Nearest(n=0) = %v, want nil", got)
+	}
+	if got := c.Nearest("", 3); got != nil {
+		t.Errorf("Nearest de intenção vazia = %v, want nil", got)
+	}
+}
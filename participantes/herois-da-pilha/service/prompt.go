@@ -0,0 +1,94 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"herois-da-pilha/data"
+)
+
+// PromptBuilder monta o prompt de classificação a partir do template, com o
+// catálogo completo de serviços, mas apenas os exemplos do dataset mais
+// parecidos com a intenção (few-shot dinâmico), reduzindo tokens e latência.
+type PromptBuilder struct {
+	tmpl     *template.Template
+	catalog  *data.Catalog
+	examples []data.IntentExample
+	index    *LocalClassifier
+	// numExamples é quantos exemplos incluir; 0 inclui todos.
+	numExamples int
+}
+
+// promptData é o contexto passado ao template.
+type promptData struct {
+	Services []promptService
+	Fallback string
+	Intent   string
+	History  []string
+}
+
+type promptService struct {
+	ID          int
+	Name        string
+	Description string
+	Aliases     []string
+	Rules       []string
+	Examples    []string
+}
+
+// NewPromptBuilder cria um PromptBuilder. numExamples <= 0 inclui todos os
+// exemplos, reproduzindo o prompt estático.
+func NewPromptBuilder(tmpl *template.Template, catalog *data.Catalog, examples []data.IntentExample, numExamples int) *PromptBuilder {
+	b := &PromptBuilder{tmpl: tmpl, catalog: catalog, examples: examples, numExamples: numExamples}
+	if numExamples > 0 {
+		b.index = NewLocalClassifier(examples)
+	}
+	return b
+}
+
+// Build retorna as mensagens de sistema e de usuário para a intenção.
+func (b *PromptBuilder) Build(intent string) (system, user string, err error) {
+	return b.BuildWithHistory(nil, intent)
+}
+
+// BuildWithHistory é como Build, mas inclui os turnos anteriores da ligação
+// (do mais antigo ao mais recente) como contexto. Os exemplos são escolhidos
+// pela solicitação atual: os turnos anteriores puxariam exemplos do assunto
+// que o cliente está corrigindo.
+func (b *PromptBuilder) BuildWithHistory(history []string, intent string) (system, user string, err error) {
+	examples := b.examples
+	if b.index != nil {
+		examples = b.index.Nearest(intent, b.numExamples)
+	}
+
+	byService := make(map[int][]string)
+	for _, ex := range examples {
+		byService[ex.ServiceID] = append(byService[ex.ServiceID], ex.Intent)
+	}
+
+	pd := promptData{Intent: intent, History: history}
+	pd.Fallback, _ = b.catalog.Name(b.catalog.FallbackID())
+	for _, svc := range b.catalog.Services {
+		pd.Services = append(pd.Services, promptService{
+			ID:          svc.ID,
+			Name:        svc.Name,
+			Description: svc.Description,
+			Aliases:     svc.Aliases,
+			Rules:       svc.Rules,
+			Examples:    byService[svc.ID],
+		})
+	}
+
+	var sb strings.Builder
+	if err := b.tmpl.ExecuteTemplate(&sb, "system", pd); err != nil {
+		return "", "", fmt.Errorf("erro ao montar prompt de sistema: %w", err)
+	}
+	system = sb.String()
+
+	sb.Reset()
+	if err := b.tmpl.ExecuteTemplate(&sb, "user", pd); err != nil {
+		return "", "", fmt.Errorf("erro ao montar prompt de usuário: %w", err)
+	}
+	return system, sb.String(), nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"herois-da-pilha/data"
+)
+
+// exampleTemplate lista um exemplo por linha, para contá-los no prompt.
+const exampleTemplate = `{{define "system"}}{{range .Services}}{{range .Examples}}EX {{.}}
+{{end}}{{end}}{{end}}{{define "user"}}{{.Intent}}{{end}}`
+
+func TestPromptBuilderNearestExamples(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte(exampleTemplate), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	const n = 4
+	res, err := LoadResources("", "", path, n)
+	if err != nil {
+		t.Fatalf("LoadResources: %v", err)
+	}
+
+	intent := "quero pagar minha fatura"
+	system, user, err := res.Prompts.Build(intent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user != intent {
+		t.Errorf("user = %q, want a intenção", user)
+	}
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(system), "\n") {
+		got = append(got, strings.TrimPrefix(line, "EX "))
+	}
+	var want []string
+	for _, ex := range NewLocalClassifier(res.Examples).Nearest(intent, n) {
+		want = append(want, ex.Intent)
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if len(got) != n || strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("exemplos no prompt = %q, want os %d mais próximos %q", got, n, want)
+	}
+}
+
+func TestPromptBuilderAllExamples(t *testing.T) {
+	tmpl, err := data.LoadPromptTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := LoadResources("", "", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// numExamples = 0 reproduz o prompt estático, com todos os exemplos
+	system, _, err := NewPromptBuilder(tmpl, res.Catalog, res.Examples, 0).Build("qualquer coisa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ex := range res.Examples {
+		if !strings.Contains(system, `"`+ex.Intent+`"`) {
+			t.Fatalf("exemplo %q ausente do prompt completo", ex.Intent)
+		}
+	}
+}
+
+func TestLoadResourcesRejectsMalformedTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte(`{{define "system"}}{{.Intent`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadResources("", "", path, 12); err == nil {
+		t.Fatal("LoadResources aceitou um template malformado")
+	}
+}
+
+func TestPromptBuilderRulesFromCatalog(t *testing.T) {
+	catalog, err := data.ParseCatalog([]byte(`{"version":"1","services":[
+		{"id":1,"name":"Fatura","rules":["Regra da fatura."]},
+		{"id":2,"name":"Operador","fallback":true}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := data.LoadPromptTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	system, _, err := NewPromptBuilder(tmpl, catalog, nil, 0).Build("oi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Regra da fatura.", `Priorize "Operador"`} {
+		if !strings.Contains(system, want) {
+			t.Errorf("prompt não contém %q", want)
+		}
+	}
+	// Nenhum serviço do catálogo padrão pode estar fixo no template
+	for _, name := range []string{"Atendimento humano", "Pagamento de contas", "Segunda via de Fatura"} {
+		if strings.Contains(system, name) {
+			t.Errorf("prompt contém %q, ausente do catálogo", name)
+		}
+	}
+}
+
+// Os exemplos do prompt vêm da solicitação atual, não da conversa inteira.
+func TestPromptBuilderExamplesFromCurrentTurn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte(exampleTemplate), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err := LoadResources("", "", path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const intent = "telefone do seguro"
+	withHistory, _, err := res.Prompts.BuildWithHistory([]string{"quero pagar minha fatura", "segunda via do boleto"}, intent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alone, _, err := res.Prompts.Build(intent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withHistory != alone {
+		t.Errorf("exemplos com histórico:\n%s\nwant os do turno atual:\n%s", withHistory, alone)
+	}
+}
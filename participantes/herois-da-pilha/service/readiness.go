@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"herois-da-pilha/llm"
+	"herois-da-pilha/util"
+)
+
+// Status dos componentes reportados por Readiness.
+const (
+	ComponentOK       = "ok"
+	ComponentFailing  = "failing"
+	ComponentWarming  = "warming"
+	ComponentDisabled = "disabled"
+)
+
+// upstreamProbe guarda o último resultado da sonda da API de IA.
+type upstreamProbe struct {
+	// ttl é por quanto tempo o resultado é reaproveitado, para que o
+	// readiness não gere uma chamada externa a cada verificação.
+	ttl time.Duration
+
+	mu       sync.Mutex
+	checked  time.Time
+	err      error
+	inflight *probeCall // sonda em andamento, compartilhada pelas verificações
+}
+
+// probeCall é uma sonda em andamento; done é fechado quando err é definido.
+type probeCall struct {
+	done chan struct{}
+	err  error
+}
+
+// Readiness verifica os componentes necessários para atender requisições:
+// chave da API, acesso à API de IA, cache aquecido e pool de workers.
+// ready é falso se qualquer componente não estiver "ok" ou "disabled".
+func (s *FinderService) Readiness(ctx context.Context) (ready bool, components map[string]util.ComponentStatus) {
+	components = make(map[string]util.ComponentStatus, 4)
+	llmClassifier := s.active.Load().llm
+
+	switch {
+	case llmClassifier == nil:
+		components["api_key"] = util.ComponentStatus{Status: ComponentDisabled, Detail: "etapa llm não configurada"}
+		components["upstream"] = util.ComponentStatus{Status: ComponentDisabled, Detail: "etapa llm não configurada"}
+	case !llmClassifier.HasAPIKey():
+		components["api_key"] = util.ComponentStatus{Status: ComponentFailing, Detail: "OPENROUTER_API_KEY não definida"}
+		components["upstream"] = util.ComponentStatus{Status: ComponentFailing, Detail: "sem chave da API"}
+	default:
+		components["api_key"] = util.ComponentStatus{Status: ComponentOK}
+		if err := s.probeUpstream(ctx, llmClassifier); err != nil {
+			components["upstream"] = util.ComponentStatus{Status: ComponentFailing, Detail: err.Error()}
+		} else {
+			components["upstream"] = util.ComponentStatus{Status: ComponentOK}
+		}
+	}
+
+	if llmClassifier != nil {
+		if states := llmClassifier.BreakerStates(); len(states) > 0 {
+			components["llm_providers"] = breakerComponent(states)
+		}
+	}
+
+	if s.Ready() {
+		components["cache"] = util.ComponentStatus{Status: ComponentOK, Detail: fmt.Sprintf("%d entradas", s.cache.Len())}
+	} else {
+		components["cache"] = util.ComponentStatus{Status: ComponentWarming}
+	}
+
+	if live := int(s.liveWorkers.Load()); live == numWorkers {
+		components["workers"] = util.ComponentStatus{Status: ComponentOK, Detail: fmt.Sprintf("%d/%d", live, numWorkers)}
+	} else {
+		components["workers"] = util.ComponentStatus{Status: ComponentFailing, Detail: fmt.Sprintf("%d/%d", live, numWorkers)}
+	}
+
+	ready = true
+	for _, c := range components {
+		if c.Status != ComponentOK && c.Status != ComponentDisabled {
+			ready = false
+		}
+	}
+	return ready, components
+}
+
+// breakerComponent resume os circuit breakers dos provedores de IA: o
+// componente falha apenas se todos estiverem abertos.
+func breakerComponent(states map[string]string) util.ComponentStatus {
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	status := ComponentFailing
+	details := make([]string, 0, len(names))
+	for _, name := range names {
+		if states[name] != llm.BreakerOpen {
+			status = ComponentOK
+		}
+		details = append(details, name+"="+states[name])
+	}
+	return util.ComponentStatus{Status: status, Detail: strings.Join(details, ", ")}
+}
+
+// probeUpstream sonda a API de IA, reaproveitando o resultado por probe.ttl.
+// A sonda roda fora do lock: verificações concorrentes aguardam a mesma
+// sonda em vez de enfileirar uma nova cada.
+func (s *FinderService) probeUpstream(ctx context.Context, llmClassifier *LLMClassifier) error {
+	s.probe.mu.Lock()
+	if !s.probe.checked.IsZero() && time.Since(s.probe.checked) < s.probe.ttl {
+		err := s.probe.err
+		s.probe.mu.Unlock()
+		return err
+	}
+	call := s.probe.inflight
+	if call == nil {
+		call = &probeCall{done: make(chan struct{})}
+		s.probe.inflight = call
+		go s.runProbe(ctx, llmClassifier, call)
+	}
+	s.probe.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runProbe executa a sonda de call e guarda o resultado.
+func (s *FinderService) runProbe(ctx context.Context, llmClassifier *LLMClassifier, call *probeCall) {
+	// A sonda não depende da requisição que a disparou: um health checker que
+	// desiste antes do fim não pode virar "API falhando" por probe.ttl.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 3*time.Second)
+	defer cancel()
+
+	call.err = llmClassifier.Probe(ctx)
+
+	s.probe.mu.Lock()
+	if !errors.Is(call.err, context.Canceled) {
+		s.probe.err = call.err
+		s.probe.checked = time.Now()
+	}
+	s.probe.inflight = nil
+	s.probe.mu.Unlock()
+	close(call.done)
+}
+
+// findLLMClassifier localiza a etapa de IA na cascata, se houver.
+func findLLMClassifier(classifier Classifier) *LLMClassifier {
+	switch c := classifier.(type) {
+	case *LLMClassifier:
+		return c
+	case *CascadeClassifier:
+		for _, stage := range c.Stages {
+			if llm := findLLMClassifier(stage.Classifier); llm != nil {
+				return llm
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// probeProvider é um provedor cuja sonda espera release e falha com err.
+type probeProvider struct {
+	probes  atomic.Int32
+	release chan struct{}
+	err     error
+}
+
+func (p *probeProvider) Name() string { return "fake" }
+
+func (p *probeProvider) CreateChatCompletion(context.Context, openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	return openai.ChatCompletionResponse{}, errors.New("não usado")
+}
+
+func (p *probeProvider) HasAPIKey() bool { return true }
+
+func (p *probeProvider) Probe(ctx context.Context) error {
+	p.probes.Add(1)
+	select {
+	case <-p.release:
+		return p.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestProbeUpstreamSharesOneProbe(t *testing.T) {
+	s := newTestFinder(t, &recordingClassifier{})
+	defer s.Close(context.Background())
+	s.probe.ttl = time.Hour
+	provider := &probeProvider{release: make(chan struct{}), err: errors.New("401")}
+	classifier := &LLMClassifier{provider: provider}
+
+	// Um health checker que desiste não espera a sonda nem a cancela
+	abandoned, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.probeUpstream(abandoned, classifier); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	const n = 5
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.probeUpstream(context.Background(), classifier)
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(provider.release)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != provider.err {
+			t.Errorf("verificação %d: err = %v, want %v", i, err, provider.err)
+		}
+	}
+	if got := provider.probes.Load(); got != 1 {
+		t.Errorf("%d sondas, want 1 compartilhada", got)
+	}
+
+	// O resultado é reaproveitado dentro do TTL e renovado depois dele
+	s.probeUpstream(context.Background(), classifier)
+	if got := provider.probes.Load(); got != 1 {
+		t.Errorf("dentro do TTL: %d sondas, want 1", got)
+	}
+	s.probe.mu.Lock()
+	s.probe.ttl = time.Nanosecond
+	s.probe.mu.Unlock()
+	s.probeUpstream(context.Background(), classifier)
+	if got := provider.probes.Load(); got != 2 {
+		t.Errorf("após o TTL: %d sondas, want 2", got)
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+
+	"herois-da-pilha/data"
+	"herois-da-pilha/util"
+)
+
+// classifierSet são os classificadores montados a partir de uma versão dos
+// recursos. Reload monta um novo conjunto e o troca atomicamente, de modo que
+// cada classificação usa uma configuração consistente do início ao fim.
+type classifierSet struct {
+	res        *Resources
+	classifier Classifier
+	ensemble   Classifier     // nil se o ensemble estiver desativado
+	llm        *LLMClassifier // etapa de IA da cascata (nil se não configurada)
+}
+
+// newClassifierSet monta a cascata (veja newCascadeFromEnv) e, se o modo
+// permitir, o ensemble (veja newEnsembleFromEnv) sobre os recursos.
+func newClassifierSet(res *Resources, ensembleMode string) *classifierSet {
+	cascade, err := newCascadeFromEnv(res)
+	if err != nil {
+		slog.Warn("cascata inválida, usando apenas a IA", "error", err)
+		cascade = &CascadeClassifier{Stages: []Stage{{Name: StageLLM, Classifier: newLLMClassifierFromEnv(res)}}}
+	}
+
+	set := &classifierSet{res: res, classifier: cascade, llm: findLLMClassifier(cascade)}
+	if ensembleMode != EnsembleOff {
+		if ensemble, err := newEnsembleFromEnv(res); err != nil {
+			slog.Warn("ensemble desativado", "error", err)
+		} else {
+			set.ensemble = ensemble
+		}
+	}
+	return set
+}
+
+// ReloadResult descreve o resultado de um Reload.
+type ReloadResult struct {
+	PreviousVersion string
+	Version         string
+	Changed         bool
+	Invalidated     int // entradas removidas do cache
+}
+
+// ConfigVersion retorna a versão da configuração ativa (veja Resources.Version).
+func (s *FinderService) ConfigVersion() string {
+	return s.active.Load().res.Version
+}
+
+// Reload relê o catálogo, o dataset e o template de prompt dos arquivos
+// configurados (veja loadResourcesFromEnv), monta novos classificadores e os
+// troca atomicamente, invalidando as entradas do cache afetadas (veja
+// invalidate). Se algo estiver inválido, a configuração ativa é mantida.
+//
+// Os provedores de IA também são recriados, então o estado dos circuit
+// breakers e os contadores de hedge recomeçam do zero.
+func (s *FinderService) Reload() (ReloadResult, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	old := s.active.Load()
+	result := ReloadResult{PreviousVersion: old.res.Version, Version: old.res.Version}
+
+	res, err := loadResourcesFromEnv()
+	if err != nil {
+		return result, fmt.Errorf("configuração rejeitada, mantendo a versão %s: %w", old.res.Version, err)
+	}
+	if res.Version == old.res.Version {
+		return result, nil
+	}
+	if s.minConfidence > 0 && res.Catalog.FallbackID() == 0 {
+		// Sem serviço de fallback, as respostas abaixo de HUMAN_FALLBACK_THRESHOLD
+		// não teriam para onde ser encaminhadas.
+		return result, fmt.Errorf("configuração rejeitada, mantendo a versão %s: o catálogo não define serviço de fallback e HUMAN_FALLBACK_THRESHOLD está ativo", old.res.Version)
+	}
+
+	set := newClassifierSet(res, s.ensembleMode)
+
+	s.swapMu.Lock()
+	s.active.Store(set)
+	result.Invalidated = s.invalidate(old.res, res)
+	s.swapMu.Unlock()
+
+	result.Version = res.Version
+	result.Changed = true
+	slog.Info("configuração recarregada",
+		"previous_version", result.PreviousVersion, "config_version", result.Version, "invalidated", result.Invalidated)
+	return result, nil
+}
+
+// invalidate remove do cache as respostas que a nova configuração pode mudar:
+//
+//   - falhas, que podem passar a ter correspondência;
+//   - todas as respostas, se o dataset de exemplos mudou (todas as etapas o usam);
+//   - respostas da IA e do ensemble, se o template ou o catálogo mudou (ambos
+//     fazem parte do prompt);
+//   - respostas roteadas ao fallback por confiança insuficiente (sem etapa)
+//     ou que apontam o serviço de fallback, se o template, o catálogo ou o
+//     serviço de fallback mudou: a confiança depende de todos os serviços;
+//   - respostas que citam um serviço removido ou alterado no catálogo.
+func (s *FinderService) invalidate(old, res *Resources) int {
+	examplesChanged := old.examplesHash != res.examplesHash
+	promptChanged := old.promptHash != res.promptHash || old.catalogHash != res.catalogHash
+	fallbackChanged := old.Catalog.FallbackID() != res.Catalog.FallbackID()
+	oldFallback := old.Catalog.FallbackID()
+	changed := changedServices(old.Catalog, res.Catalog)
+
+	return s.cache.DeleteFunc(func(_ string, resp util.FindServiceResponse) bool {
+		switch {
+		case !resp.Success, examplesChanged:
+			return true
+		case promptChanged && (resp.Stage == StageLLM || resp.Stage == StageEnsemble):
+			return true
+		case (promptChanged || fallbackChanged) && (resp.Stage == "" || resp.Data.ServiceID == oldFallback):
+			return true
+		case changed[resp.Data.ServiceID]:
+			return true
+		}
+		for _, c := range resp.Candidates {
+			if changed[c.ServiceID] {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// changedServices retorna os IDs dos serviços de old removidos ou alterados em res.
+func changedServices(old, res *data.Catalog) map[int]bool {
+	changed := make(map[int]bool)
+	for _, svc := range old.Services {
+		if current, ok := res.Lookup(svc.ID); !ok || !reflect.DeepEqual(svc, current) {
+			changed[svc.ID] = true
+		}
+	}
+	return changed
+}
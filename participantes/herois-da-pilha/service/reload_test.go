@@ -0,0 +1,86 @@
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"herois-da-pilha/util"
+)
+
+// Com HUMAN_FALLBACK_THRESHOLD ativo, um catálogo sem serviço de fallback
+// deixaria as respostas de baixa confiança sem destino.
+func TestReloadRejectsCatalogWithoutFallback(t *testing.T) {
+	raw, err := os.ReadFile("../data/catalog.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var catalog map[string]any
+	if err := json.Unmarshal(raw, &catalog); err != nil {
+		t.Fatal(err)
+	}
+	for _, svc := range catalog["services"].([]any) {
+		delete(svc.(map[string]any), "fallback")
+	}
+	raw, _ = json.Marshal(catalog)
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CATALOG_PATH", path)
+
+	s := newTestFinder(t, &recordingClassifier{})
+	s.minConfidence = 0.5
+	version := s.ConfigVersion()
+
+	if _, err := s.Reload(); err == nil {
+		t.Fatal("Reload aceitou um catálogo sem fallback")
+	}
+	if s.ConfigVersion() != version {
+		t.Errorf("versão ativa = %s, want %s", s.ConfigVersion(), version)
+	}
+
+	s.minConfidence = 0
+	if result, err := s.Reload(); err != nil || !result.Changed {
+		t.Errorf("sem o limiar: Reload = %+v, %v; want aceito", result, err)
+	}
+}
+
+// As respostas roteadas ao fallback não têm etapa, mas dependem da confiança
+// calculada sobre o catálogo inteiro.
+func TestInvalidateFallbackRouted(t *testing.T) {
+	s := newTestFinder(t, &recordingClassifier{})
+	old := s.active.Load().res
+
+	ok := func(id int, stage string) util.FindServiceResponse {
+		return util.FindServiceResponse{Success: true, Data: util.ServiceData{ServiceID: id}, Stage: stage}
+	}
+	fill := func() {
+		s.cache.Set("roteada", ok(old.Catalog.FallbackID(), ""), time.Hour)
+		s.cache.Set("exata", ok(3, StageExact), time.Hour)
+	}
+	cached := func(key string) bool {
+		_, found := s.cache.Get(key)
+		return found
+	}
+
+	// Sem mudanças, nada é removido
+	fill()
+	if n := s.invalidate(old, old); n != 0 {
+		t.Errorf("sem mudanças: %d entradas removidas, want 0", n)
+	}
+
+	catalogChanged := *old
+	catalogChanged.catalogHash = "outro"
+	fill()
+	s.invalidate(old, &catalogChanged)
+	if cached("roteada") {
+		t.Error("resposta roteada ao fallback sobreviveu à mudança do catálogo")
+	}
+	if !cached("exata") {
+		t.Error("resposta exata de um serviço inalterado foi removida")
+	}
+
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"text/template"
+
+	"herois-da-pilha/data"
+	"herois-da-pilha/util"
+)
+
+// Resources reúne os dados que alimentam os classificadores: o catálogo de
+// serviços, o dataset de exemplos (CSV + exemplos do catálogo) e o montador
+// de prompts derivado de ambos.
+type Resources struct {
+	// Version identifica o conteúdo carregado: a versão do catálogo seguida
+	// de um hash do catálogo, do dataset e do template (ex: "2025.1-3f2a9c1b").
+	Version  string
+	Catalog  *data.Catalog
+	Examples []data.IntentExample
+	Prompts  *PromptBuilder
+
+	// Hashes de cada parte, usados para decidir o que invalidar no reload.
+	catalogHash  string
+	examplesHash string
+	promptHash   string
+}
+
+// LoadResources carrega e valida os recursos. Caminhos vazios usam as cópias
+// embutidas no binário. fewShot é o número de exemplos por prompt (0 = todos).
+func LoadResources(catalogPath, intentsPath, templatePath string, fewShot int) (*Resources, error) {
+	catalog, err := data.LoadCatalog(catalogPath)
+	if err != nil {
+		return nil, err
+	}
+
+	examples, err := data.LoadIntentExamples(intentsPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := catalog.CheckExamples(examples); err != nil {
+		return nil, err
+	}
+	examples = append(examples, catalog.IntentExamples()...)
+
+	tmpl, err := data.LoadPromptTemplate(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Resources{
+		Catalog:      catalog,
+		Examples:     examples,
+		Prompts:      NewPromptBuilder(tmpl, catalog, examples, fewShot),
+		catalogHash:  hashJSON(catalog),
+		examplesHash: hashJSON(examples),
+		promptHash:   hashTemplate(tmpl, fewShot),
+	}
+	res.Version = catalog.Version + "-" + hashString(res.catalogHash + res.examplesHash + res.promptHash)[:8]
+	return res, nil
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashJSON(v any) string {
+	raw, _ := json.Marshal(v)
+	return hashString(string(raw))
+}
+
+// hashTemplate resume o conteúdo de todos os templates definidos e o número
+// de exemplos por prompt.
+func hashTemplate(tmpl *template.Template, fewShot int) string {
+	templates := tmpl.Templates()
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name() < templates[j].Name() })
+
+	text := fmt.Sprintf("fewshot=%d\n", fewShot)
+	for _, t := range templates {
+		if t.Tree != nil && t.Tree.Root != nil {
+			text += t.Name() + "\n" + t.Tree.Root.String() + "\n"
+		}
+	}
+	return hashString(text)
+}
+
+// loadResourcesFromEnv carrega os recursos a partir de:
+//
+//	CATALOG_PATH         catálogo de serviços em JSON (default: cópia embutida)
+//	INTENTS_CSV_PATH     dataset de exemplos (default: cópia embutida)
+//	PROMPT_TEMPLATE_PATH template do prompt (default: cópia embutida)
+//	FEWSHOT_EXAMPLES     exemplos mais parecidos por prompt (default 12, 0 = todos)
+func loadResourcesFromEnv() (*Resources, error) {
+	res, err := LoadResources(
+		os.Getenv("CATALOG_PATH"),
+		os.Getenv("INTENTS_CSV_PATH"),
+		os.Getenv("PROMPT_TEMPLATE_PATH"),
+		util.GetEnvInt("FEWSHOT_EXAMPLES", 12),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("configuração carregada",
+		"config_version", res.Version, "services", len(res.Catalog.Services), "examples", len(res.Examples))
+	return res, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"herois-da-pilha/cache"
+	"herois-da-pilha/normalize"
+)
+
+// SessionStore guarda os turnos recentes de cada ligação (intenções já
+// normalizadas), para que as classificações seguintes da mesma sessão
+// considerem o contexto ("quero cancelar" → "não, é o seguro"). É limitado em
+// número de sessões (as menos recentes saem primeiro) e cada sessão expira
+// após ttl sem novos turnos.
+type SessionStore struct {
+	mu       sync.Mutex // serializa o ler-acrescentar-gravar de Append
+	sessions *cache.LRU[[]string]
+	ttl      time.Duration
+	maxTurns int
+}
+
+// NewSessionStore cria um SessionStore com até maxSessions sessões, cada uma
+// guardando os últimos maxTurns turnos.
+func NewSessionStore(maxSessions int, ttl time.Duration, maxTurns int) *SessionStore {
+	return &SessionStore{
+		sessions: cache.NewLRU(cache.Options[[]string]{MaxEntries: maxSessions}),
+		ttl:      ttl,
+		maxTurns: maxTurns,
+	}
+}
+
+// History retorna os turnos da sessão, do mais antigo ao mais recente.
+func (st *SessionStore) History(id string) []string {
+	turns, _ := st.sessions.Get(id)
+	return turns
+}
+
+// Append acrescenta um turno à sessão (criando-a se necessário), descartando
+// os mais antigos além de maxTurns, e renova a expiração.
+func (st *SessionStore) Append(id, intent string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	turns, _ := st.sessions.Get(id)
+	turns = append(turns[:len(turns):len(turns)], intent)
+	if len(turns) > st.maxTurns {
+		turns = turns[len(turns)-st.maxTurns:]
+	}
+	st.sessions.Set(id, turns, st.ttl)
+}
+
+// End encerra a sessão e retorna quantos turnos ela tinha; ok é falso se a
+// sessão não existir (ou já tiver expirado).
+func (st *SessionStore) End(id string) (turns int, ok bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	history, ok := st.sessions.Get(id)
+	if ok {
+		st.sessions.Delete(id)
+	}
+	return len(history), ok
+}
+
+// Len retorna o número de sessões guardadas (incluindo as expiradas ainda
+// não removidas).
+func (st *SessionStore) Len() int {
+	return st.sessions.Len()
+}
+
+// history retorna os turnos anteriores a considerar na classificação: o
+// histórico enviado pelo cliente, se houver, ou o da sessão.
+func (s *FinderService) history(opts FindOptions) []string {
+	if len(opts.History) > 0 {
+		history := make([]string, 0, len(opts.History))
+		for _, turn := range opts.History {
+			if turn = normalize.Normalize(turn); turn != "" {
+				history = append(history, turn)
+			}
+		}
+		if len(history) > s.sessions.maxTurns {
+			history = history[len(history)-s.sessions.maxTurns:]
+		}
+		return history
+	}
+	if opts.SessionID != "" {
+		return s.sessions.History(opts.SessionID)
+	}
+	return nil
+}
+
+// EndSession encerra a sessão (veja SessionStore.End).
+func (s *FinderService) EndSession(id string) (turns int, ok bool) {
+	return s.sessions.End(id)
+}
+
+// SessionCount retorna o número de sessões guardadas.
+func (s *FinderService) SessionCount() int {
+	return s.sessions.Len()
+}
+
+// contextualIntent junta os turnos anteriores e o atual no texto usado pelas
+// etapas lexicais e locais, que não distinguem turnos.
+func contextualIntent(history []string, intent string) string {
+	if len(history) == 0 {
+		return intent
+	}
+	return strings.Join(history, " ") + " " + intent
+}
+
+type conversationKey struct{}
+
+// conversation são os turnos de uma classificação com histórico.
+type conversation struct {
+	history []string
+	current string
+}
+
+// withConversation registra em ctx os turnos anteriores e o atual, para que
+// o LLMClassifier monte o prompt com o histórico separado da solicitação.
+func withConversation(ctx context.Context, history []string, current string) context.Context {
+	return context.WithValue(ctx, conversationKey{}, conversation{history: history, current: current})
+}
+
+// conversationFrom retorna a conversa registrada em ctx, se houver.
+func conversationFrom(ctx context.Context) (conversation, bool) {
+	c, ok := ctx.Value(conversationKey{}).(conversation)
+	return c, ok
+}
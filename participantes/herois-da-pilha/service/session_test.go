@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionStoreBounds(t *testing.T) {
+	st := NewSessionStore(2, time.Hour, 2)
+
+	st.Append("a", "um")
+	st.Append("a", "dois")
+	st.Append("a", "tres")
+	if got := st.History("a"); !reflect.DeepEqual(got, []string{"dois", "tres"}) {
+		t.Errorf("History = %q, want só os 2 últimos turnos", got)
+	}
+
+	// A terceira sessão tira a menos recente
+	st.Append("b", "um")
+	st.Append("c", "um")
+	if st.History("a") != nil || st.Len() != 2 {
+		t.Errorf("sessão a = %q, Len = %d; want removida e 2 sessões", st.History("a"), st.Len())
+	}
+
+	if turns, ok := st.End("b"); !ok || turns != 1 {
+		t.Errorf("End = %d, %v; want 1, true", turns, ok)
+	}
+	if _, ok := st.End("b"); ok {
+		t.Error("sessão encerrada duas vezes")
+	}
+}
+
+func TestSessionStoreTTL(t *testing.T) {
+	st := NewSessionStore(10, 30*time.Millisecond, 3)
+	st.Append("a", "um")
+	time.Sleep(20 * time.Millisecond)
+	st.Append("a", "dois") // renova a expiração
+	time.Sleep(20 * time.Millisecond)
+	if got := st.History("a"); len(got) != 2 {
+		t.Fatalf("History = %q, want a sessão renovada pelo último turno", got)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if got := st.History("a"); got != nil {
+		t.Errorf("History = %q, want sessão expirada", got)
+	}
+	if _, ok := st.End("a"); ok {
+		t.Error("End encontrou uma sessão expirada")
+	}
+}
+
+// turnClassifier responde com confiança baixa às intenções em weak e registra
+// as chamadas, indicando se a conversa estava no contexto.
+type turnClassifier struct {
+	weak map[string]bool
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (c *turnClassifier) Classify(ctx context.Context, intent string) (Prediction, error) {
+	call := intent
+	if conv, ok := conversationFrom(ctx); ok {
+		call = "conversa:" + strings.Join(conv.history, "|") + "|" + conv.current
+	}
+	c.mu.Lock()
+	c.calls = append(c.calls, call)
+	c.mu.Unlock()
+
+	if c.weak[intent] {
+		return Prediction{ServiceID: 7, Score: 0.3, Candidates: []Candidate{{ServiceID: 7, Confidence: 0.3}}}, nil
+	}
+	return Prediction{ServiceID: 8, Score: 0.9, Candidates: []Candidate{{ServiceID: 8, Confidence: 0.9}}}, nil
+}
+
+func (c *turnClassifier) takeCalls() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	calls := c.calls
+	c.calls = nil
+	return calls
+}
+
+func TestHistoryOnlyForWeakTurn(t *testing.T) {
+	classifier := &turnClassifier{weak: map[string]bool{"quero cancelar": true, "nao e o seguro": true}}
+	s := newTestFinder(t, classifier)
+	ctx := context.Background()
+
+	// Primeiro turno: sem histórico, uma única classificação
+	s.FindService(ctx, "quero cancelar", FindOptions{SessionID: "ligacao"})
+	if calls := classifier.takeCalls(); !reflect.DeepEqual(calls, []string{"quero cancelar"}) {
+		t.Errorf("primeiro turno: chamadas = %q", calls)
+	}
+
+	// Turno fraco sozinho: reclassificado com o texto da conversa e, para a
+	// IA, com os turnos separados no contexto
+	resp := s.FindService(ctx, "não, é o seguro", FindOptions{SessionID: "ligacao"})
+	want := []string{"nao e o seguro", "conversa:quero cancelar|nao e o seguro"}
+	if calls := classifier.takeCalls(); !reflect.DeepEqual(calls, want) {
+		t.Errorf("turno fraco: chamadas = %q, want %q", calls, want)
+	}
+	if !resp.Success || resp.Data.ServiceID != 8 {
+		t.Errorf("turno fraco: %+v, want o serviço da classificação com histórico", resp)
+	}
+
+	// Turno confiante sozinho: o histórico não é usado
+	resp = s.FindService(ctx, "telefone do seguro", FindOptions{SessionID: "ligacao"})
+	if calls := classifier.takeCalls(); !reflect.DeepEqual(calls, []string{"telefone do seguro"}) {
+		t.Errorf("turno confiante: chamadas = %q", calls)
+	}
+	if resp.Data.ServiceID != 8 {
+		t.Errorf("turno confiante: %+v", resp)
+	}
+}
+
+func TestHistoryInCacheKey(t *testing.T) {
+	classifier := &turnClassifier{weak: map[string]bool{"sim": true}}
+	s := newTestFinder(t, classifier)
+	ctx := context.Background()
+
+	for _, history := range [][]string{nil, {"quero cancelar"}, {"segunda via"}, {"quero cancelar"}} {
+		s.FindService(ctx, "sim", FindOptions{History: history})
+	}
+	// A última requisição repete o histórico da segunda e vem do cache
+	want := []string{"sim", "sim", "conversa:quero cancelar|sim", "sim", "conversa:segunda via|sim"}
+	if calls := classifier.takeCalls(); !reflect.DeepEqual(calls, want) {
+		t.Errorf("chamadas = %q, want %q", calls, want)
+	}
+
+	if cacheKey("sim", nil, false) == cacheKey("sim", []string{"quero cancelar"}, false) {
+		t.Error("a chave do cache ignora o histórico")
+	}
+}
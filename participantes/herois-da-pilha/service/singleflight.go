@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"herois-da-pilha/util"
+)
+
+// flightGroup deduplica classificações em andamento: chamadas concorrentes
+// com a mesma chave (intenção normalizada) compartilham uma única execução e
+// recebem o mesmo resultado.
+//
+// A execução compartilhada roda com um contexto próprio, que só é cancelado
+// quando todos os interessados desistiram (contexto cancelado), de modo que a
+// saída de um cliente não derruba a resposta dos demais e uma execução
+// abandonada por todos não continua consumindo a API. O prazo de quem iniciou
+// a execução é mantido, para que ela não siga além dele esperando os demais.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done     chan struct{}
+	response util.FindServiceResponse
+	waiters  int
+	cancel   context.CancelFunc
+}
+
+// Do executa fn para a chave, a menos que já exista uma execução em andamento,
+// caso em que aguarda e reaproveita o resultado dela. shared indica se o
+// resultado veio de outra chamada. Se ctx for cancelado antes do resultado,
+// Do retorna imediatamente com o erro do contexto.
+func (g *flightGroup) Do(ctx context.Context, key string, fn func(ctx context.Context) util.FindServiceResponse) (response util.FindServiceResponse, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	call, shared := g.calls[key]
+	if shared {
+		call.waiters++
+	} else {
+		// Preserva os valores do contexto (ex: request ID) e o prazo, mas não o
+		// cancelamento
+		var (
+			callCtx context.Context
+			cancel  context.CancelFunc
+		)
+		if deadline, ok := ctx.Deadline(); ok {
+			callCtx, cancel = context.WithDeadline(context.WithoutCancel(ctx), deadline)
+		} else {
+			callCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+		}
+		call = &flightCall{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		g.calls[key] = call
+
+		go func() {
+			call.response = fn(callCtx)
+
+			g.mu.Lock()
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+
+			close(call.done)
+			cancel()
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.response, shared, nil
+	case <-ctx.Done():
+		g.leave(key, call)
+		return util.FindServiceResponse{}, shared, ctx.Err()
+	}
+}
+
+// leave remove um interessado da execução, cancelando-a se foi o último.
+func (g *flightGroup) leave(key string, call *flightCall) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	call.waiters--
+	if call.waiters == 0 {
+		call.cancel()
+		if g.calls[key] == call {
+			delete(g.calls, key)
+		}
+	}
+}
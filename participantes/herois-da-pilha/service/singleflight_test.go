@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"herois-da-pilha/util"
+)
+
+// blockingCall é uma execução que só termina quando release é fechado ou seu
+// contexto é cancelado (registrado em canceled).
+type blockingCall struct {
+	calls    atomic.Int32
+	started  chan struct{}
+	release  chan struct{}
+	canceled chan struct{}
+}
+
+func newBlockingCall() *blockingCall {
+	return &blockingCall{started: make(chan struct{}, 10), release: make(chan struct{}), canceled: make(chan struct{})}
+}
+
+func (b *blockingCall) fn(ctx context.Context) util.FindServiceResponse {
+	b.calls.Add(1)
+	b.started <- struct{}{}
+	select {
+	case <-b.release:
+		return util.FindServiceResponse{Success: true, Data: util.ServiceData{ServiceID: 7}}
+	case <-ctx.Done():
+		close(b.canceled)
+		return util.FindServiceResponse{Error: ctx.Err().Error()}
+	}
+}
+
+// waitWaiters espera a execução da chave ter n interessados.
+func waitWaiters(t *testing.T, g *flightGroup, key string, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		g.mu.Lock()
+		call := g.calls[key]
+		ok := call != nil && call.waiters == n
+		g.mu.Unlock()
+		if ok {
+			return
+		}
+	}
+	t.Fatalf("a execução de %q não chegou a %d interessados", key, n)
+}
+
+func TestFlightGroupSharesOneCall(t *testing.T) {
+	var g flightGroup
+	b := newBlockingCall()
+
+	const n = 5
+	var wg sync.WaitGroup
+	var sharedCount atomic.Int32
+	responses := make([]util.FindServiceResponse, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, shared, err := g.Do(context.Background(), "k", b.fn)
+			if err != nil {
+				t.Errorf("Do: %v", err)
+			}
+			if shared {
+				sharedCount.Add(1)
+			}
+			responses[i] = resp
+		}(i)
+	}
+	waitWaiters(t, &g, "k", n)
+	close(b.release)
+	wg.Wait()
+
+	if calls := b.calls.Load(); calls != 1 {
+		t.Errorf("fn executada %d vezes, want 1", calls)
+	}
+	if got := sharedCount.Load(); got != n-1 {
+		t.Errorf("%d resultados compartilhados, want %d", got, n-1)
+	}
+	for i, r := range responses {
+		if !r.Success || r.Data.ServiceID != 7 {
+			t.Errorf("resposta %d = %+v", i, r)
+		}
+	}
+
+	// Terminada a execução, a chave é liberada para uma nova
+	b2 := newBlockingCall()
+	close(b2.release)
+	if _, shared, _ := g.Do(context.Background(), "k", b2.fn); shared || b2.calls.Load() != 1 {
+		t.Error("uma nova chamada após o término deveria executar fn de novo")
+	}
+}
+
+func TestFlightGroupWaiterCancelDoesNotAffectOthers(t *testing.T) {
+	var g flightGroup
+	b := newBlockingCall()
+
+	leaderDone := make(chan util.FindServiceResponse)
+	go func() {
+		resp, _, _ := g.Do(context.Background(), "k", b.fn)
+		leaderDone <- resp
+	}()
+	<-b.started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	followerErr := make(chan error)
+	go func() {
+		_, _, err := g.Do(ctx, "k", b.fn)
+		followerErr <- err
+	}()
+	waitWaiters(t, &g, "k", 2)
+
+	cancel()
+	if err := <-followerErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("o interessado cancelado recebeu %v, want context.Canceled", err)
+	}
+	select {
+	case <-b.canceled:
+		t.Fatal("a execução foi cancelada com um interessado restante")
+	default:
+	}
+
+	close(b.release)
+	if resp := <-leaderDone; !resp.Success {
+		t.Errorf("o interessado restante recebeu %+v", resp)
+	}
+}
+
+func TestFlightGroupLastWaiterCancelsCall(t *testing.T) {
+	var g flightGroup
+	b := newBlockingCall()
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	for _, ctx := range []context.Context{ctx1, ctx2} {
+		go func(ctx context.Context) {
+			_, _, err := g.Do(ctx, "k", b.fn)
+			errs <- err
+		}(ctx)
+	}
+	waitWaiters(t, &g, "k", 2)
+
+	cancel1()
+	<-errs
+	cancel2()
+	<-errs
+
+	select {
+	case <-b.canceled:
+	case <-time.After(time.Second):
+		t.Fatal("a execução não foi cancelada quando o último interessado desistiu")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.calls) != 0 {
+		t.Errorf("a execução abandonada continua registrada: %v", g.calls)
+	}
+}
+
+// A execução compartilhada mantém o prazo de quem a iniciou.
+func TestFlightGroupKeepsDeadline(t *testing.T) {
+	var g flightGroup
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	want, _ := ctx.Deadline()
+
+	var got time.Time
+	var ok bool
+	g.Do(ctx, "k", func(callCtx context.Context) util.FindServiceResponse {
+		got, ok = callCtx.Deadline()
+		return util.FindServiceResponse{Success: true}
+	})
+	if !ok || !got.Equal(want) {
+		t.Errorf("prazo da execução = %v (%v), want %v", got, ok, want)
+	}
+
+	g.Do(context.Background(), "k", func(callCtx context.Context) util.FindServiceResponse {
+		_, ok = callCtx.Deadline()
+		return util.FindServiceResponse{Success: true}
+	})
+	if ok {
+		t.Error("execução sem prazo recebeu um prazo")
+	}
+}
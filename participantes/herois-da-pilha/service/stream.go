@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"herois-da-pilha/util"
+)
+
+// StreamItem é uma intenção de FindServiceStream; ID identifica o resultado.
+type StreamItem struct {
+	ID     string
+	Intent string
+}
+
+// FindServiceStream classifica os itens obtidos de next até que ele retorne
+// false (ou ctx seja cancelado), chamando emit com cada resposta assim que a
+// classificação termina, fora da ordem de chegada. emit pode ser chamada
+// concorrentemente.
+//
+// No máximo numWorkers itens (a capacidade do pool que consome jobChannel)
+// ficam em andamento: next só é chamada quando um deles termina e emit
+// retorna. Assim, tanto um pool ocupado quanto um consumidor lento de emit
+// seguram a leitura da entrada, e a memória não cresce com o tamanho do fluxo.
+// Retorna depois que todas as chamadas a emit terminarem.
+func (s *FinderService) FindServiceStream(ctx context.Context, next func() (StreamItem, bool), emit func(StreamItem, util.FindServiceResponse), opts FindOptions) {
+	sem := make(chan struct{}, numWorkers)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		item, ok := next()
+		if !ok {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			emit(item, s.FindService(ctx, item.Intent, opts))
+		}()
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"herois-da-pilha/cache"
+	"herois-da-pilha/data"
+	"herois-da-pilha/util"
+)
+
+// LoadSnapshot carrega no cache o snapshot gravado em snapshotPath, se houver
+// e se tiver sido gravado com a configuração ativa: as respostas de outra
+// versão (catálogo, dataset ou template diferentes) podem citar serviços que
+// não existem mais.
+func (s *FinderService) LoadSnapshot() error {
+	if s.snapshotPath == "" {
+		return nil
+	}
+
+	n, err := cache.LoadFile(s.snapshotPath, s.cache, s.ConfigVersion())
+	if errors.Is(err, cache.ErrStaleSnapshot) {
+		slog.Info("snapshot do cache descartado", "path", s.snapshotPath, "reason", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	slog.Info("snapshot do cache carregado", "path", s.snapshotPath, "entries", n)
+	return nil
+}
+
+// SaveSnapshot grava as respostas de sucesso do cache em snapshotPath. Não
+// faz nada se a persistência estiver desativada ou se o cache não suportar
+// snapshots.
+func (s *FinderService) SaveSnapshot() error {
+	if s.snapshotPath == "" {
+		return nil
+	}
+
+	snapshotter, ok := s.cache.(cache.Snapshotter[util.FindServiceResponse])
+	if !ok {
+		return nil
+	}
+
+	_, err := cache.SaveFile(s.snapshotPath, successOnly{snapshotter}, s.ConfigVersion())
+	return err
+}
+
+// successOnly deixa as falhas fora do snapshot: elas só valem pelo TTL
+// negativo e não devem sobreviver a um restart.
+type successOnly struct {
+	cache.Snapshotter[util.FindServiceResponse]
+}
+
+// Entries implementa cache.Snapshotter.
+func (s successOnly) Entries() []cache.Entry[util.FindServiceResponse] {
+	var entries []cache.Entry[util.FindServiceResponse]
+	for _, e := range s.Snapshotter.Entries() {
+		if e.Value.Success {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// startSnapshotLoop grava o snapshot do cache periodicamente, até o Close.
+func (s *FinderService) startSnapshotLoop(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.SaveSnapshot(); err != nil {
+					slog.Error("erro ao gravar snapshot do cache", "error", err)
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// Prewarm classifica todos os exemplos informados para popular o cache,
+// usando no máximo concurrency classificações simultâneas. O serviço só é
+// considerado pronto (Ready) quando o pre-warm termina.
+func (s *FinderService) Prewarm(examples []data.IntentExample, concurrency int) {
+	s.ready.Store(false)
+	defer s.ready.Store(true)
+
+	start := time.Now()
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, ex := range examples {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(intent string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.FindService(s.workCtx, intent, FindOptions{})
+		}(ex.Intent)
+	}
+	wg.Wait()
+
+	slog.Info("pre-warm concluído", "intents", len(examples), "duration", time.Since(start).String())
+}
+
+// Ready indica se o serviço terminou o pre-warm do cache.
+func (s *FinderService) Ready() bool {
+	return s.ready.Load()
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"herois-da-pilha/cache"
+	"herois-da-pilha/util"
+)
+
+// blockingClassifier avisa em started e só responde quando ctx é cancelado.
+type blockingClassifier struct {
+	started chan struct{}
+}
+
+func (c blockingClassifier) Classify(ctx context.Context, _ string) (Prediction, error) {
+	c.started <- struct{}{}
+	<-ctx.Done()
+	return Prediction{}, ctx.Err()
+}
+
+// Classificações abortadas pelo encerramento não podem ficar no cache nem
+// no snapshot, e as falhas em geral não sobrevivem a um restart.
+func TestCloseAbortDoesNotPersistFailures(t *testing.T) {
+	classifier := blockingClassifier{started: make(chan struct{}, 1)}
+	s := newTestFinder(t, classifier)
+	s.negativeTTL = time.Minute
+	s.snapshotPath = filepath.Join(t.TempDir(), "cache.json")
+	s.cache.Set("sucesso", util.FindServiceResponse{Success: true, Data: util.ServiceData{ServiceID: 1}}, time.Hour)
+	s.cache.Set("falha", util.FindServiceResponse{Error: "x"}, time.Minute)
+
+	done := make(chan util.FindServiceResponse)
+	go func() { done <- s.FindService(context.Background(), "em andamento", FindOptions{}) }()
+	<-classifier.started
+
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Close(expired); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if resp := <-done; resp.Success {
+		t.Fatalf("resposta = %+v, want falha", resp)
+	}
+	if _, ok := s.cache.Get("em andamento"); ok {
+		t.Error("a falha do aborto foi armazenada no cache")
+	}
+
+	restored := cache.NewLRU(cache.Options[util.FindServiceResponse]{})
+	if _, err := cache.LoadFile(s.snapshotPath, restored, s.ConfigVersion()); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, e := range restored.Entries() {
+		keys = append(keys, e.Key)
+	}
+	if len(keys) != 1 || keys[0] != "sucesso" {
+		t.Errorf("snapshot = %v, want apenas a resposta de sucesso", keys)
+	}
+}
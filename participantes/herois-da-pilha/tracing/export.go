@@ -0,0 +1,335 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"herois-da-pilha/util"
+)
+
+// Exporter envia lotes de spans encerrados.
+type Exporter interface {
+	Export(ctx context.Context, payload []byte) error
+	Close() error
+}
+
+// SetupFromEnv ativa o tracing conforme:
+//
+//	TRACE_EXPORTER              "" (desativado, default), "otlp" ou "file"
+//	OTEL_EXPORTER_OTLP_ENDPOINT coletor OTLP/HTTP (default http://localhost:4318)
+//	TRACE_FILE                  arquivo do exportador "file" (default traces.jsonl)
+//	TRACE_SAMPLE_RATIO          fração das traces registradas (default 1)
+//	OTEL_SERVICE_NAME           service.name dos spans (default herois-da-pilha)
+//
+// O exportador "file" grava uma requisição OTLP/JSON por linha, o mesmo
+// formato do file exporter do OpenTelemetry Collector.
+func SetupFromEnv() error {
+	var exporter Exporter
+	switch kind := util.GetEnv("TRACE_EXPORTER", ""); kind {
+	case "":
+		return nil
+	case "otlp":
+		exporter = NewOTLPExporter(util.GetEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"))
+	case "file":
+		var err error
+		if exporter, err = NewFileExporter(util.GetEnv("TRACE_FILE", "traces.jsonl")); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("TRACE_EXPORTER inválido: %q (use otlp ou file)", kind)
+	}
+
+	Setup(exporter, util.GetEnv("OTEL_SERVICE_NAME", "herois-da-pilha"), util.GetEnvFloat("TRACE_SAMPLE_RATIO", 1))
+	slog.Info("tracing ativo", "exporter", util.GetEnv("TRACE_EXPORTER", ""))
+	return nil
+}
+
+// Setup ativa o tracing com o exportador informado.
+func Setup(exporter Exporter, serviceName string, ratio float64) {
+	b := &batcher{
+		exporter:    exporter,
+		serviceName: serviceName,
+		spans:       make(chan *Span, 2048),
+		done:        make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.run()
+
+	tracer.mu.Lock()
+	tracer.exporter = b
+	tracer.ratio = ratio
+	tracer.mu.Unlock()
+}
+
+// Shutdown desativa o tracing, exporta os spans pendentes e fecha o exportador.
+func Shutdown(ctx context.Context) error {
+	tracer.mu.Lock()
+	b := tracer.exporter
+	tracer.exporter = nil
+	tracer.mu.Unlock()
+	if b == nil {
+		return nil
+	}
+
+	close(b.done)
+	finished := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return b.exporter.Close()
+}
+
+// batcher acumula spans e os exporta em lotes, fora do caminho da requisição.
+type batcher struct {
+	exporter    Exporter
+	serviceName string
+	spans       chan *Span
+	done        chan struct{}
+	wg          sync.WaitGroup
+}
+
+const (
+	maxBatch      = 256
+	flushInterval = 5 * time.Second
+)
+
+// enqueue descarta o span se a fila estiver cheia, para nunca bloquear a requisição.
+func (b *batcher) enqueue(s *Span) {
+	select {
+	case b.spans <- s:
+	default:
+	}
+}
+
+func (b *batcher) run() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	for {
+		select {
+		case s := <-b.spans:
+			if batch = append(batch, s); len(batch) >= maxBatch {
+				b.flush(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			b.flush(batch)
+			batch = nil
+		case <-b.done:
+			for {
+				select {
+				case s := <-b.spans:
+					batch = append(batch, s)
+				default:
+					b.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (b *batcher) flush(batch []*Span) {
+	if len(batch) == 0 {
+		return
+	}
+	payload, err := json.Marshal(encodeRequest(b.serviceName, batch))
+	if err != nil {
+		slog.Error("erro ao codificar spans", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.exporter.Export(ctx, payload); err != nil {
+		slog.Warn("erro ao exportar spans", "spans", len(batch), "error", err)
+	}
+}
+
+// FileExporter grava cada lote como uma linha de OTLP/JSON.
+type FileExporter struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewFileExporter abre (ou cria) o arquivo em modo append.
+func NewFileExporter(path string) (*FileExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo de traces: %w", err)
+	}
+	return &FileExporter{f: f}, nil
+}
+
+// Export implementa Exporter.
+func (e *FileExporter) Export(_ context.Context, payload []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := e.f.Write(append(payload, '\n'))
+	return err
+}
+
+// Close implementa Exporter.
+func (e *FileExporter) Close() error {
+	return e.f.Close()
+}
+
+// OTLPExporter envia os lotes para um coletor via OTLP/HTTP com JSON.
+type OTLPExporter struct {
+	url    string
+	client *http.Client
+}
+
+// NewOTLPExporter cria um exportador para o coletor em endpoint
+// (ex: http://localhost:4318); os spans vão para {endpoint}/v1/traces.
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	return &OTLPExporter{
+		url:    strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Export implementa Exporter.
+func (e *OTLPExporter) Export(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("coletor respondeu com status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close implementa Exporter.
+func (e *OTLPExporter) Close() error {
+	return nil
+}
+
+// Estruturas do ExportTraceServiceRequest do OTLP em JSON. IDs vão em
+// hexadecimal e timestamps (uint64) como string, conforme a especificação.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"` // 0 = unset, 2 = error
+		Message string `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+func encodeRequest(serviceName string, spans []*Span) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		if s.parentID != ([8]byte{}) {
+			span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		for _, a := range s.attrs {
+			span.Attributes = append(span.Attributes, encodeAttribute(a.key, a.value))
+		}
+		if s.err != "" {
+			span.Status = otlpStatus{Code: 2, Message: s.err}
+		}
+		s.mu.Unlock()
+		encoded = append(encoded, span)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpKeyValue{encodeAttribute("service.name", serviceName)}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "herois-da-pilha/tracing"}, Spans: encoded}},
+	}}}
+}
+
+func encodeAttribute(key string, value any) otlpKeyValue {
+	var v otlpValue
+	switch x := value.(type) {
+	case string:
+		v.StringValue = &x
+	case bool:
+		v.BoolValue = &x
+	case int:
+		s := strconv.Itoa(x)
+		v.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(x, 10)
+		v.IntValue = &s
+	case float64:
+		v.DoubleValue = &x
+	default:
+		s := fmt.Sprint(x)
+		v.StringValue = &s
+	}
+	return otlpKeyValue{Key: key, Value: v}
+}
@@ -0,0 +1,178 @@
+// Package tracing implementa spans compatíveis com OpenTelemetry, exportados
+// em OTLP/JSON para um coletor (OTLP/HTTP) ou para um arquivo, sem depender
+// do SDK do OpenTelemetry. Com o tracing desativado, Start não aloca spans e
+// os métodos de *Span são no-ops.
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tipos de span (SpanKind do OTLP).
+const (
+	KindInternal = 1
+	KindServer   = 2
+	KindClient   = 3
+)
+
+// Span é uma operação rastreada. Um *Span nil é válido e ignora tudo.
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	sampled  bool
+
+	mu    sync.Mutex
+	name  string
+	kind  int
+	start time.Time
+	end   time.Time
+	attrs []attribute
+	err   string
+	ended bool
+}
+
+type attribute struct {
+	key   string
+	value any
+}
+
+type spanKey struct{}
+
+// tracer é o estado global configurado por Setup.
+var tracer struct {
+	mu       sync.RWMutex
+	exporter *batcher
+	ratio    float64
+}
+
+// Start inicia um span filho do span em ctx (ou uma nova trace) e retorna o
+// contexto com o novo span. O span deve ser encerrado com End.
+func Start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	tracer.mu.RLock()
+	enabled, ratio := tracer.exporter != nil, tracer.ratio
+	tracer.mu.RUnlock()
+	if !enabled {
+		return ctx, nil
+	}
+
+	parent, _ := ctx.Value(spanKey{}).(*Span)
+	s := &Span{name: name, kind: kind, start: time.Now()}
+	if parent != nil {
+		s.traceID, s.parentID, s.sampled = parent.traceID, parent.spanID, parent.sampled
+	} else {
+		fillRandom(s.traceID[:])
+		s.sampled = rand.Float64() < ratio
+	}
+	fillRandom(s.spanID[:])
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// FromContext retorna o span ativo em ctx, ou nil.
+func FromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// Set registra um atributo (string, bool, inteiros ou float64).
+func (s *Span) Set(key string, value any) {
+	if s == nil || !s.sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attribute{key: key, value: value})
+}
+
+// SetError marca o span com erro. err nil é ignorado.
+func (s *Span) SetError(err error) {
+	if s == nil || !s.sampled || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End encerra o span e o envia ao exportador. Chamadas repetidas são ignoradas.
+func (s *Span) End() {
+	if s == nil || !s.sampled {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	tracer.mu.RLock()
+	exporter := tracer.exporter
+	tracer.mu.RUnlock()
+	if exporter != nil {
+		exporter.enqueue(s)
+	}
+}
+
+// TraceID retorna o ID da trace em hexadecimal, ou "" se s for nil.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// WithRemoteParent retorna um contexto cujo span pai é o informado no
+// cabeçalho W3C traceparent ("00-<trace-id>-<span-id>-<flags>"), para que os
+// spans do serviço continuem a trace de quem chamou. Cabeçalhos inválidos são
+// ignorados.
+func WithRemoteParent(ctx context.Context, traceparent string) context.Context {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return ctx
+	}
+
+	remote := &Span{}
+	if _, err := hex.Decode(remote.traceID[:], []byte(parts[1])); err != nil {
+		return ctx
+	}
+	if _, err := hex.Decode(remote.spanID[:], []byte(parts[2])); err != nil {
+		return ctx
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return ctx
+	}
+	remote.sampled = flags[0]&1 == 1
+	return context.WithValue(ctx, spanKey{}, remote)
+}
+
+// Traceparent formata o span como cabeçalho W3C traceparent.
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	flags := "00"
+	if s.sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%x-%x-%s", s.traceID, s.spanID, flags)
+}
+
+func fillRandom(b []byte) {
+	for i := 0; i < len(b); i += 8 {
+		v := rand.Uint64()
+		for j := i; j < len(b) && j < i+8; j++ {
+			b[j] = byte(v)
+			v >>= 8
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDisabledIsNoop(t *testing.T) {
+	ctx, span := Start(context.Background(), "op", KindInternal)
+	if span != nil || FromContext(ctx) != nil {
+		t.Fatal("Start com o tracing desativado deveria retornar span nil")
+	}
+	span.Set("k", "v")
+	span.SetError(errors.New("x"))
+	span.End()
+}
+
+func TestWithRemoteParent(t *testing.T) {
+	ctx := WithRemoteParent(context.Background(), "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	parent := FromContext(ctx)
+	if parent.TraceID() != "0af7651916cd43dd8448eb211c80319c" || !parent.sampled {
+		t.Fatalf("traceparent interpretado incorretamente: %s", parent.Traceparent())
+	}
+
+	for _, invalid := range []string{"", "00-xyz-b7ad6b7169203331-01", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331"} {
+		if FromContext(WithRemoteParent(context.Background(), invalid)) != nil {
+			t.Errorf("traceparent inválido aceito: %q", invalid)
+		}
+	}
+}
+
+func TestFileExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	exporter, err := NewFileExporter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Setup(exporter, "teste", 1)
+
+	ctx := WithRemoteParent(context.Background(), "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	ctx, root := Start(ctx, "root", KindServer)
+	_, child := Start(ctx, "child", KindClient)
+	child.Set("gen_ai.usage.input_tokens", 42)
+	child.SetError(errors.New("falhou"))
+	child.End()
+	root.End()
+
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req otlpRequest
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(raw))), &req); err != nil {
+		t.Fatalf("linha não é OTLP/JSON: %v", err)
+	}
+
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("len(spans) = %d, want 2", len(spans))
+	}
+	c, r := spans[0], spans[1]
+	if c.TraceID != "0af7651916cd43dd8448eb211c80319c" || c.ParentSpanID != r.SpanID || r.ParentSpanID != "b7ad6b7169203331" {
+		t.Errorf("hierarquia incorreta: root=%+v child=%+v", r, c)
+	}
+	if c.Status.Code != 2 || *c.Attributes[0].Value.IntValue != "42" {
+		t.Errorf("status ou atributos incorretos: %+v", c)
+	}
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetEnv retorna o valor da variável de ambiente ou o default se ela não estiver definida.
+func GetEnv(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
+
+// GetEnvFloat retorna a variável de ambiente como float64, ou o default se ausente/inválida.
+func GetEnvFloat(key string, def float64) float64 {
+	v, err := strconv.ParseFloat(GetEnv(key, ""), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetEnvInt retorna a variável de ambiente como int, ou o default se ausente/inválida.
+func GetEnvInt(key string, def int) int {
+	v, err := strconv.Atoi(GetEnv(key, ""))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetEnvDuration retorna a variável de ambiente como time.Duration (ex: "30s"),
+// ou o default se ausente/inválida.
+func GetEnvDuration(key string, def time.Duration) time.Duration {
+	v, err := time.ParseDuration(GetEnv(key, ""))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetEnvBool retorna a variável de ambiente como bool ("true", "1", ...),
+// ou o default se ausente/inválida.
+func GetEnvBool(key string, def bool) bool {
+	v, err := strconv.ParseBool(GetEnv(key, ""))
+	if err != nil {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,181 @@
+package util
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SetupLogger configura o logger padrão do slog a partir de:
+//
+//	LOG_FORMAT "json" (default) ou "text"
+//	LOG_LEVEL  "debug", "info" (default), "warn" ou "error"
+//
+// Os registros feitos com um contexto de requisição recebem o campo request_id.
+func SetupLogger() {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(GetEnv("LOG_LEVEL", "info"))); err != nil {
+		level = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler = slog.NewJSONHandler(os.Stdout, opts)
+	if strings.EqualFold(GetEnv("LOG_FORMAT", "json"), "text") {
+		h = slog.NewTextHandler(os.Stdout, opts)
+	}
+	slog.SetDefault(slog.New(NewContextHandler(h)))
+}
+
+// NewContextHandler envolve h para acrescentar aos registros o request_id do
+// contexto.
+func NewContextHandler(h slog.Handler) slog.Handler {
+	return contextHandler{h}
+}
+
+// contextHandler acrescenta aos registros o request_id do contexto.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+type requestIDKey struct{}
+
+// WithRequestID associa o ID da requisição ao contexto.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID retorna o ID da requisição associado ao contexto, se houver.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID gera um ID de requisição aleatório.
+func NewRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// IntentHash resume a intenção para os logs, sem registrar o texto do cliente.
+func IntentHash(intent string) string {
+	sum := sha256.Sum256([]byte(intent))
+	return hex.EncodeToString(sum[:6])
+}
+
+// StageTiming é o tempo gasto por uma etapa de classificação.
+type StageTiming struct {
+	Stage string  `json:"stage"`
+	Ms    float64 `json:"ms"`
+}
+
+// UpstreamCall descreve uma chamada à API de IA.
+type UpstreamCall struct {
+	Model            string  `json:"model"`
+	LatencyMs        float64 `json:"latency_ms"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+}
+
+// RequestLog acumula os campos de log de uma requisição enquanto ela passa
+// pelas camadas (cache, cascata, IA), para que seja registrada em uma única
+// linha ao final. Os métodos aceitam receptor nil, então quem registra não
+// precisa saber se há um RequestLog no contexto. Seguro para uso concorrente.
+type RequestLog struct {
+	mu       sync.Mutex
+	attrs    []any
+	stages   []StageTiming
+	upstream []UpstreamCall
+}
+
+type requestLogKey struct{}
+
+// WithRequestLog associa o RequestLog ao contexto.
+func WithRequestLog(ctx context.Context, l *RequestLog) context.Context {
+	return context.WithValue(ctx, requestLogKey{}, l)
+}
+
+// RequestLogFrom retorna o RequestLog do contexto, ou nil.
+func RequestLogFrom(ctx context.Context) *RequestLog {
+	l, _ := ctx.Value(requestLogKey{}).(*RequestLog)
+	return l
+}
+
+// Set registra um campo.
+func (l *RequestLog) Set(key string, value any) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.attrs = append(l.attrs, slog.Any(key, value))
+}
+
+// AddStage registra o tempo gasto por uma etapa.
+func (l *RequestLog) AddStage(stage string, d time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stages = append(l.stages, StageTiming{Stage: stage, Ms: durationMs(d)})
+}
+
+// AddUpstream registra uma chamada à API de IA.
+func (l *RequestLog) AddUpstream(model string, latency time.Duration, promptTokens, completionTokens int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.upstream = append(l.upstream, UpstreamCall{
+		Model:            model,
+		LatencyMs:        durationMs(latency),
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+	})
+}
+
+// Attrs retorna os campos acumulados, no formato aceito por slog.Logger.Info.
+func (l *RequestLog) Attrs() []any {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	attrs := append([]any(nil), l.attrs...)
+	if len(l.stages) > 0 {
+		attrs = append(attrs, slog.Any("stages", l.stages))
+	}
+	if len(l.upstream) > 0 {
+		attrs = append(attrs, slog.Any("upstream", l.upstream))
+	}
+	return attrs
+}
+
+// durationMs converte a duração em milissegundos com precisão de microssegundos.
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
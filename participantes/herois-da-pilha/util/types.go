@@ -1,28 +1,51 @@
 package util
 
-// Define os serviços válidos em um mapa (ID -> Nome do Serviço).
-var ValidServices = map[int]string{
-	1:  "Consulta Limite / Vencimento do cartão / Melhor dia de compra",
-	2:  "Segunda via de boleto de acordo",
-	3:  "Segunda via de Fatura",
-	4:  "Status de Entrega do Cartão",
-	5:  "Status de cartão",
-	6:  "Solicitação de aumento de limite",
-	7:  "Cancelamento de cartão",
-	8:  "Telefones de seguradoras",
-	9:  "Desbloqueio de Cartão",
-	10: "Esqueceu senha / Troca de senha",
-	11: "Perda e roubo",
-	12: "Consulta do Saldo",
-	13: "Pagamento de contas",
-	14: "Reclamações",
-	15: "Atendimento humano",
-	16: "Token de proposta",
-}
+import (
+	"context"
+
+	"herois-da-pilha/cache"
+)
+
+// ErrorCode é o código de erro estável retornado em FindServiceResponse, para
+// que os clientes decidam pelo código em vez de interpretar a mensagem.
+type ErrorCode string
+
+// Códigos de erro de /api/find-service (veja docs/error_codes.md).
+const (
+	ErrInvalidBody        ErrorCode = "INVALID_BODY"
+	ErrMethodNotAllowed   ErrorCode = "METHOD_NOT_ALLOWED"
+	ErrNoMatch            ErrorCode = "NO_MATCH"
+	ErrUpstreamTimeout    ErrorCode = "UPSTREAM_TIMEOUT"
+	ErrUpstreamError      ErrorCode = "UPSTREAM_ERROR"
+	ErrInvalidModelOutput ErrorCode = "INVALID_MODEL_OUTPUT"
+	ErrShuttingDown       ErrorCode = "SHUTTING_DOWN"
+	ErrCanceled           ErrorCode = "CANCELED"
+	ErrInternal           ErrorCode = "INTERNAL"
+	ErrBatchTooLarge      ErrorCode = "BATCH_TOO_LARGE"
+	ErrNeedsClarification ErrorCode = "NEEDS_CLARIFICATION"
+)
 
 // FindServiceRequest é o corpo da requisição POST /api/find-service
 type FindServiceRequest struct {
 	Intent string `json:"intent" binding:"required"`
+	// TopK (opcional) ativa o modo detalhado: retorna os K serviços mais
+	// prováveis com suas confianças. Também aceito via query param ?top_k=K.
+	TopK int `json:"top_k,omitempty"`
+	// Ensemble (opcional) pede ou dispensa a votação entre vários modelos;
+	// ausente segue o padrão da implantação. Também aceito via ?ensemble=true.
+	Ensemble *bool `json:"ensemble,omitempty"`
+	// SessionID (opcional) identifica a ligação: os turnos anteriores da
+	// sessão são usados como contexto e a intenção atual é acrescentada a ela.
+	SessionID string `json:"session_id,omitempty"`
+	// History (opcional) são os turnos anteriores da ligação, do mais antigo
+	// ao mais recente; se presente, substitui o histórico da sessão.
+	History []string `json:"history,omitempty"`
+	// Clarify (opcional) pede uma pergunta de esclarecimento em vez da falha
+	// NO_MATCH quando a intenção é ambígua. Também aceito via ?clarify=true.
+	Clarify bool `json:"clarify,omitempty"`
+	// ClarificationToken (opcional) indica que Intent é a resposta do cliente
+	// à pergunta de esclarecimento que retornou o token.
+	ClarificationToken string `json:"clarification_token,omitempty"`
 }
 
 // ServiceData é a estrutura de dados retornada para o serviço encontrado
@@ -31,26 +54,141 @@ type ServiceData struct {
 	ServiceName string `json:"service_name"`
 }
 
+// ServiceCandidate é um serviço candidato com a confiança calibrada (0 a 1).
+type ServiceCandidate struct {
+	ServiceID   int     `json:"service_id"`
+	ServiceName string  `json:"service_name"`
+	Confidence  float64 `json:"confidence"`
+}
+
 // FindServiceResponse é o corpo da resposta POST /api/find-service
+//
+// Confidence, Candidates e Stage só são preenchidos no modo detalhado
+// (top_k > 0); no modo padrão a resposta mantém o formato original.
 type FindServiceResponse struct {
 	Success bool        `json:"success"`
 	Data    ServiceData `json:"data"`
 	Error   string      `json:"error,omitempty"`
+	// ErrorCode identifica o tipo de falha; veja docs/error_codes.md.
+	ErrorCode  ErrorCode          `json:"error_code,omitempty"`
+	Confidence float64            `json:"confidence,omitempty"`
+	Candidates []ServiceCandidate `json:"candidates,omitempty"`
+	// Stage é a etapa que classificou a intenção (exact, fuzzy, local, llm,
+	// ensemble, cache ou clarification).
+	Stage string `json:"stage,omitempty"`
+	// Clarification só é preenchido com o código NEEDS_CLARIFICATION.
+	Clarification *Clarification `json:"clarification,omitempty"`
+}
+
+// Clarification é o pedido de esclarecimento de uma intenção ambígua: a
+// pergunta a ser feita ao cliente e os serviços plausíveis. A resposta do
+// cliente deve ser enviada como intent com o Token em clarification_token.
+type Clarification struct {
+	Question string             `json:"question"`
+	Options  []ServiceCandidate `json:"options"`
+	Token    string             `json:"token"`
+}
+
+// BatchItem é uma intenção do lote, identificada pelo cliente.
+type BatchItem struct {
+	ID     string `json:"id"`
+	Intent string `json:"intent"`
+}
+
+// FindServiceBatchRequest é o corpo da requisição POST /api/find-service/batch.
+// TopK e Ensemble valem para todos os itens, como em FindServiceRequest.
+type FindServiceBatchRequest struct {
+	Items    []BatchItem `json:"items"`
+	TopK     int         `json:"top_k,omitempty"`
+	Ensemble *bool       `json:"ensemble,omitempty"`
+}
+
+// BatchResult é o resultado de um item do lote: o ID informado pelo cliente e
+// os mesmos campos de FindServiceResponse.
+type BatchResult struct {
+	ID string `json:"id"`
+	FindServiceResponse
+}
+
+// FindServiceBatchResponse é o corpo da resposta POST /api/find-service/batch.
+// Success indica que o lote foi aceito; o resultado de cada item, inclusive
+// as falhas, vem em Results, na ordem dos itens.
+type FindServiceBatchResponse struct {
+	Success   bool          `json:"success"`
+	Results   []BatchResult `json:"results"`
+	Error     string        `json:"error,omitempty"`
+	ErrorCode ErrorCode     `json:"error_code,omitempty"`
+}
+
+// StatsResponse é o corpo da resposta GET /api/stats
+type StatsResponse struct {
+	// Stages conta as respostas por etapa da cascata ("cache" para cache hits).
+	Stages map[string]uint64 `json:"stages"`
+	Cache  cache.Stats       `json:"cache"`
+	// Coalesced conta as requisições que reaproveitaram uma classificação em andamento.
+	Coalesced uint64 `json:"coalesced"`
+	// Hedges só é preenchido quando as chamadas especulativas estão ativas.
+	Hedges *HedgeStats `json:"hedges,omitempty"`
+}
+
+// HedgeStats conta as chamadas especulativas à IA.
+type HedgeStats struct {
+	Fired uint64 `json:"fired"`
+	Won   uint64 `json:"won"`
 }
 
 // HealthzResponse é o corpo da resposta GET /api/healthz
 type HealthzResponse struct {
+	Status        string `json:"status"`
+	ConfigVersion string `json:"config_version,omitempty"`
+}
+
+// EndSessionResponse é o corpo da resposta DELETE /api/sessions/{id}
+type EndSessionResponse struct {
+	Success bool   `json:"success"`
+	Turns   int    `json:"turns"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReloadResponse é o corpo da resposta POST /api/admin/reload
+type ReloadResponse struct {
+	Success         bool   `json:"success"`
+	Version         string `json:"version"`
+	PreviousVersion string `json:"previous_version"`
+	Changed         bool   `json:"changed"`
+	Invalidated     int    `json:"invalidated"`
+	Error           string `json:"error,omitempty"`
+}
+
+// ComponentStatus é o estado de um componente verificado pelo readiness.
+type ComponentStatus struct {
 	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ReadyzResponse é o corpo da resposta GET /api/readyz
+type ReadyzResponse struct {
+	Status        string                     `json:"status"`
+	ConfigVersion string                     `json:"config_version,omitempty"`
+	Components    map[string]ComponentStatus `json:"components"`
 }
 
 // AIResponse é a estrutura esperada (e forçada) do modelo de IA
 type AIResponse struct {
 	ServiceID   string `json:"service_id"`
 	ServiceName string `json:"service_name"`
+	// Candidates são os IDs plausíveis indicados pelo modelo quando ele não
+	// tem certeza (service_id vazio).
+	Candidates []string `json:"candidates,omitempty"`
 }
 
 // JobRequest empacota a intenção e um canal de resposta para a solicitação.
+// Ctx carrega o cancelamento/prazo do solicitante até o worker; ResponseChan
+// deve ter buffer para que o worker nunca bloqueie se o solicitante desistir.
 type JobRequest struct {
+	Ctx          context.Context
 	Intent       string
+	Ensemble     bool     // usar o classificador ensemble em vez da cascata
+	History      []string // turnos anteriores da ligação (normalizados), se houver
 	ResponseChan chan FindServiceResponse
 }